@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"codeingame-csb/internal/bronzebot"
+)
+
+func FuzzGameInput(f *testing.F) {
+	transcripts, _ := filepath.Glob(filepath.Join("testdata", "*.in"))
+	for _, transcript := range transcripts {
+		if data, err := ioutil.ReadFile(transcript); err == nil {
+			f.Add(data)
+		}
+	}
+	f.Add([]byte("1000 1000 1000 1000 0 0\n1000 1000\n"))
+	f.Add([]byte("0 0 5000 0 -300 181\n400 0\n"))
+	f.Add([]byte("0 0 5000 0 -1900 0\n9000 9000\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var out bytes.Buffer
+		bronzebot.Run(bytes.NewReader(data), &out)
+		lines := bufio.NewScanner(&out)
+		for lines.Scan() {
+			fields := strings.Fields(lines.Text())
+			if len(fields) != 3 {
+				t.Fatalf("command %q", lines.Text())
+			}
+			if fields[2] == "BOOST" || fields[2] == "SHIELD" {
+				continue
+			}
+			if thrust, err := strconv.Atoi(fields[2]); err != nil || thrust < 0 || thrust > 100 {
+				t.Fatalf("command %q has thrust %q", lines.Text(), fields[2])
+			}
+		}
+	})
+}
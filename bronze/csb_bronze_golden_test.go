@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeingame-csb/internal/bronzebot"
+)
+
+func TestMain(m *testing.M) {
+	os.Stderr, _ = os.Open(os.DevNull)
+	os.Exit(m.Run())
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files with what the bot outputs now")
+
+// TestGoldenTurns feeds the transcripts in testdata to the bot and compares
+// its commands with the golden files next to them. The transcripts are
+// single pod races of three laps that the heuristics finish, so the bot
+// learns the track on the way; seed N was recorded with
+//
+//	go run ./gold record -seed N -league bronze > bronze/testdata/seedN.in
+//
+// After a change of behavior that is meant, rewrite the golden files with
+//
+//	go test ./bronze -run TestGoldenTurns -update
+func TestGoldenTurns(t *testing.T) {
+	transcripts, err := filepath.Glob(filepath.Join("testdata", "*.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transcript := range transcripts {
+		t.Run(filepath.Base(transcript), func(t *testing.T) {
+			in, err := os.Open(transcript)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			var out bytes.Buffer
+			if err := bronzebot.Run(in, &out); err != nil {
+				t.Fatal(err)
+			}
+			golden := strings.TrimSuffix(transcript, ".in") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareCommands(t, strings.Split(string(want), "\n"), strings.Split(out.String(), "\n"))
+		})
+	}
+}
+
+// compareCommands reports the turns on which the bot's commands changed,
+// one line a turn.
+func compareCommands(t *testing.T, want, got []string) {
+	t.Helper()
+	changed := 0
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			if changed++; changed <= 20 {
+				t.Errorf("turn %d: got %q, want %q", i+1, g, w)
+			}
+		}
+	}
+	if changed > 20 {
+		t.Errorf("and %d more changed commands", changed-20)
+	}
+}
@@ -1,30 +1,27 @@
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
+	"math/rand"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
+// SmartVector is a vector of the heuristics. Its length and angles are
+// worked out when asked for, as most vectors only need a few of them.
 type SmartVector struct {
-	x, y         float64
-	length       float64
-	angleDegrees float64
-	angleRadians float64
+	x, y float64
 }
 
 func NewSmartVectorCartesian(x, y float64) SmartVector {
-	angleRadians := cartesianToRadian(x, y)
-	angleRadians = normalizeAngleRadian(angleRadians)
-	angleDegrees := angleRadians * 180.0 / math.Pi
-	smartVector := SmartVector{
-		x:            x,
-		y:            y,
-		length:       math.Sqrt(x*x + y*y),
-		angleDegrees: angleDegrees,
-		angleRadians: angleRadians,
-	}
-	return smartVector
+	return SmartVector{x: x, y: y}
 }
 
 func NewSmartVectorPolar(length, angleDegrees float64) SmartVector {
@@ -32,16 +29,24 @@ func NewSmartVectorPolar(length, angleDegrees float64) SmartVector {
 		return NewSmartVectorCartesian(0, 0)
 	}
 	angleRadians := angleDegrees * math.Pi / 180
-	angleRadians = normalizeAngleRadian(angleRadians)
-	angleDegrees = angleRadians * 180.0 / math.Pi
-	smartVector := SmartVector{
-		x:            length * math.Cos(angleRadians),
-		y:            length * math.Sin(angleRadians),
-		length:       length,
-		angleDegrees: angleDegrees,
-		angleRadians: angleRadians,
+	return SmartVector{
+		x: length * math.Cos(angleRadians),
+		y: length * math.Sin(angleRadians),
 	}
-	return smartVector
+}
+
+func (sv SmartVector) length() float64 {
+	return math.Sqrt(sv.x*sv.x + sv.y*sv.y)
+}
+
+// angleRadians is the angle of the vector in (-Pi, Pi].
+func (sv SmartVector) angleRadians() float64 {
+	return normalizeAngleRadian(cartesianToRadian(sv.x, sv.y))
+}
+
+// angleDegrees is the angle of the vector in (-180, 180].
+func (sv SmartVector) angleDegrees() float64 {
+	return sv.angleRadians() * 180.0 / math.Pi
 }
 
 func (sv SmartVector) GetXYAsInts() (int, int) {
@@ -49,11 +54,7 @@ func (sv SmartVector) GetXYAsInts() (int, int) {
 }
 
 func (sv SmartVector) multiplyNumber(factor float64) SmartVector {
-	if sv.length == 0 || math.IsNaN(sv.angleRadians) {
-		return NewSmartVectorCartesian(0, 0)
-	} else {
-		return NewSmartVectorCartesian(sv.x*factor, sv.y*factor)
-	}
+	return NewSmartVectorCartesian(sv.x*factor, sv.y*factor)
 }
 
 func (sv SmartVector) addVector(otherVector SmartVector) SmartVector {
@@ -64,19 +65,218 @@ func (sv SmartVector) subtractVector(otherVector SmartVector) SmartVector {
 	return NewSmartVectorCartesian(sv.x-otherVector.x, sv.y-otherVector.y)
 }
 
+// Vec2 is a plain vector for the physics and the searches. Unlike
+// SmartVector it works nothing out up front: the length and the angles are
+// only computed when asked for.
+type Vec2 struct {
+	X, Y float64
+}
+
+// Vec2Polar is the vector of the given length pointing at angleDegrees.
+func Vec2Polar(length, angleDegrees float64) Vec2 {
+	sin, cos := math.Sincos(angleDegrees * math.Pi / 180)
+	return Vec2{length * cos, length * sin}
+}
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec2) Scale(factor float64) Vec2 {
+	return Vec2{v.X * factor, v.Y * factor}
+}
+
+func (v Vec2) Dot(o Vec2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Cross is the z of the 3D cross product, positive when o is at a positive
+// angle from v.
+func (v Vec2) Cross(o Vec2) float64 {
+	return v.X*o.Y - v.Y*o.X
+}
+
+func (v Vec2) LengthSquared() float64 {
+	return v.X*v.X + v.Y*v.Y
+}
+
+func (v Vec2) Length() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+}
+
+func (v Vec2) Distance(o Vec2) float64 {
+	return v.Sub(o).Length()
+}
+
+func (v Vec2) DistanceSquared(o Vec2) float64 {
+	return v.Sub(o).LengthSquared()
+}
+
+// Normalize is the vector of length 1 in the same direction; the zero
+// vector stays zero.
+func (v Vec2) Normalize() Vec2 {
+	length := v.Length()
+	if length == 0 {
+		return v
+	}
+	return Vec2{v.X / length, v.Y / length}
+}
+
+// Radians is the angle of the vector in (-Pi, Pi], 0 for the zero vector.
+func (v Vec2) Radians() float64 {
+	return cartesianToRadian(v.X, v.Y)
+}
+
+// Degrees is the angle of the vector in (-180, 180], 0 for the zero vector.
+func (v Vec2) Degrees() float64 {
+	return cartesianToRadian(v.X, v.Y) * 180 / math.Pi
+}
+
+func (v Vec2) Angle() Angle {
+	return AngleRadians(cartesianToRadian(v.X, v.Y))
+}
+
+func (v Vec2) Rotate(degrees float64) Vec2 {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	return Vec2{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Perpendicular is the vector rotated by 90 degrees.
+func (v Vec2) Perpendicular() Vec2 {
+	return Vec2{-v.Y, v.X}
+}
+
+// AngleBetween is the angle in degrees, in (-180, 180], to turn v by to
+// point along o.
+func (v Vec2) AngleBetween(o Vec2) float64 {
+	return math.Atan2(v.Cross(o), v.Dot(o)) * 180 / math.Pi
+}
+
+// Project is the part of v along onto; zero when onto is.
+func (v Vec2) Project(onto Vec2) Vec2 {
+	lengthSquared := onto.LengthSquared()
+	if lengthSquared == 0 {
+		return Vec2{}
+	}
+	return onto.Scale(v.Dot(onto) / lengthSquared)
+}
+
+// Reflect is v bounced off a wall with the given normal, which doesn't need
+// to be of length 1.
+func (v Vec2) Reflect(normal Vec2) Vec2 {
+	return v.Sub(v.Project(normal).Scale(2))
+}
+
+// Lerp is the point t of the way from v to o.
+func (v Vec2) Lerp(o Vec2, t float64) Vec2 {
+	return Vec2{v.X + (o.X-v.X)*t, v.Y + (o.Y-v.Y)*t}
+}
+
+func (v Vec2) SmartVector() SmartVector {
+	return NewSmartVectorCartesian(v.X, v.Y)
+}
+
+func (sv SmartVector) Vec2() Vec2 {
+	return Vec2{sv.x, sv.y}
+}
+
+// Angle is a direction, kept in degrees in [0, 360) like the pods' angles in
+// the game's input: 0 is east and angles grow clockwise on screen, where y
+// goes down.
+type Angle struct {
+	degrees float64
+}
+
+func AngleDegrees(degrees float64) Angle {
+	return Angle{wrapDegrees(degrees)}
+}
+
+func AngleRadians(radians float64) Angle {
+	return Angle{wrapDegrees(radians * 180 / math.Pi)}
+}
+
+// wrapDegrees brings any number of degrees into [0, 360).
+func wrapDegrees(degrees float64) float64 {
+	if degrees < -360 || degrees >= 720 {
+		degrees = math.Mod(degrees, 360)
+	}
+	if degrees < 0 {
+		degrees += 360
+	} else if degrees >= 360 {
+		degrees -= 360
+	}
+	if degrees >= 360 {
+		return 0 // a tiny negative angle plus 360 rounds up to 360
+	}
+	return degrees
+}
+
+func (a Angle) Degrees() float64 {
+	return a.degrees
+}
+
+func (a Angle) Radians() float64 {
+	return a.degrees * math.Pi / 180
+}
+
+// Add turns the angle by the given degrees.
+func (a Angle) Add(degrees float64) Angle {
+	return AngleDegrees(a.degrees + degrees)
+}
+
+// Turn is how many degrees, in [-180, 180), to turn from a to face to.
+func (a Angle) Turn(to Angle) float64 {
+	delta := to.degrees - a.degrees
+	if delta >= 180 {
+		delta -= 360
+	} else if delta < -180 {
+		delta += 360
+	}
+	return delta
+}
+
+// Towards turns from a to face to, by at most maxTurn degrees.
+func (a Angle) Towards(to Angle, maxTurn float64) Angle {
+	turn := a.Turn(to)
+	if turn > maxTurn {
+		turn = maxTurn
+	} else if turn < -maxTurn {
+		turn = -maxTurn
+	}
+	return a.Add(turn)
+}
+
+// Lerp is the angle t of the way from a to to, turning the short way.
+func (a Angle) Lerp(to Angle, t float64) Angle {
+	return a.Add(a.Turn(to) * t)
+}
+
+// Round is the angle to the whole degree, as the referee keeps it.
+func (a Angle) Round() Angle {
+	return AngleDegrees(math.Round(a.degrees))
+}
+
+// cartesianToRadian is the angle of (x, y) in (-Pi, Pi], in the game's
+// convention: 0 is east and angles grow clockwise on screen, where y goes
+// down. Zero vectors, of either sign, point east, and infinite coordinates
+// keep their direction.
 func cartesianToRadian(x, y float64) float64 {
-	angleRadians := math.Atan(y / x)
-	if x < 0 && y >= 0 {
-		return angleRadians + math.Pi
-	} // quadrant 2
-	if x < 0 && y < 0 {
-		return angleRadians - math.Pi
-	} // quadrant 3
-	return angleRadians // default for quadrant 1 and quadrant 4
+	if x == 0 {
+		x = 0 // no negative zero, it would turn the angle around
+	}
+	if y == 0 {
+		y = 0
+	}
+	return math.Atan2(y, x)
 }
 
 func normalizeAngleRadian(angle float64) float64 {
 	// Normalize to game standard between -180 and 180 degrees
+	angle = math.Mod(angle, math.Pi*2)
 	if angle > math.Pi {
 		return angle - (math.Pi * 2)
 	}
@@ -104,6 +304,10 @@ type gameState struct {
 	lastlap        bool
 	players        [2]gamer
 	opponents      [2]gamer
+	plan           *annealPlan // last turn's plan, when annealing
+	modes          [2]string   // how the heuristics moved our pods this turn
+	commands       [2]command  // what our pods played last turn
+	commandsKnown  bool        // whether commands are known, not on the first turn
 }
 
 func initGameState(track map[int]*checkpoint, numlaps int) gameState {
@@ -113,20 +317,40 @@ func initGameState(track map[int]*checkpoint, numlaps int) gameState {
 		first:          true,
 		usedboost:      false,
 		lastlap:        false,
-		players:        [2]gamer{gamer{0, 0, 0, 0, 0, 0, 0, 1}, gamer{0, 0, 0, 0, 0, 0, 0, 1}},
-		opponents:      [2]gamer{gamer{0, 0, 0, 0, 0, 0, 0, 1}, gamer{0, 0, 0, 0, 0, 0, 0, 1}},
+		players:        [2]gamer{gamer{0, 0, 0, 0, 0, 0, 0, 1, 0}, gamer{0, 0, 0, 0, 0, 0, 0, 1, 0}},
+		opponents:      [2]gamer{gamer{0, 0, 0, 0, 0, 0, 0, 1, 0}, gamer{0, 0, 0, 0, 0, 0, 0, 1, 0}},
 	}
 	return state
 }
 
 type gamer struct {
 	x, y, vx, vy, angle, nextCheckPointId, advancement, currentlap int
+	shield                                                         int // turns from this one the pod is still shielded for
 }
 
 func (g gamer) currentSpeedV() (SmartVector) {
 	return NewSmartVectorCartesian(float64(g.vx), float64(g.vy))
 }
 
+// command is what a pod outputs for one turn: a target point followed by
+// the thrust, or BOOST/SHIELD instead of the thrust.
+type command struct {
+	x, y   int
+	thrust int
+	boost  bool
+	shield bool
+}
+
+func (c command) String() string {
+	if c.boost {
+		return fmt.Sprintf("%d %d BOOST", c.x, c.y)
+	}
+	if c.shield {
+		return fmt.Sprintf("%d %d SHIELD", c.x, c.y)
+	}
+	return fmt.Sprintf("%d %d %d", c.x, c.y, c.thrust)
+}
+
 func calculateAimpoints(track map[int]*checkpoint) {
 	nextpoint := track[0]
 	for id := len(track) - 1; id >= 0; id-- {
@@ -145,6 +369,7 @@ func calculateAimpoints(track map[int]*checkpoint) {
 
 func normalizeAngleDegrees(angle int) int {
 	// Normalize to game standard between -180 and 180 degrees
+	angle = angle % 360
 	if angle > 180 {
 		return angle - 360
 	}
@@ -155,22 +380,116 @@ func normalizeAngleDegrees(angle int) int {
 }
 
 func futureCollisionCourse(main, candidate gamer, dangerzonedist, lookaheadsteps int) (int, SmartVector) {
-	mainV := NewSmartVectorCartesian(float64(main.x), float64(main.y))
-	mainSpeedV := main.currentSpeedV()
-	candV := NewSmartVectorCartesian(float64(candidate.x), float64(candidate.y))
-	candSpeedV := candidate.currentSpeedV()
+	mainV, mainSpeedV := Vec2{float64(main.x), float64(main.y)}, Vec2{float64(main.vx), float64(main.vy)}
+	candV, candSpeedV := Vec2{float64(candidate.x), float64(candidate.y)}, Vec2{float64(candidate.vx), float64(candidate.vy)}
+	dangerzone := float64(dangerzonedist) * float64(dangerzonedist)
 	for step := 1; step <= lookaheadsteps; step++ {
-		mainStepV := mainV.addVector(mainSpeedV.multiplyNumber(float64(step)))
-		candStepV := candV.addVector(candSpeedV.multiplyNumber(float64(step)))
-		distanceV := mainStepV.subtractVector(candStepV)
-		if distanceV.length <= float64(dangerzonedist) {
-			return step, mainSpeedV.multiplyNumber(float64(step))
+		mainStepV := mainV.Add(mainSpeedV.Scale(float64(step)))
+		candStepV := candV.Add(candSpeedV.Scale(float64(step)))
+		if mainStepV.DistanceSquared(candStepV) <= dangerzone {
+			return step, main.currentSpeedV().multiplyNumber(float64(step))
 		}
 	}
 	return 0, NewSmartVectorCartesian(0, 0)
 }
 
-func movePlayer(playerId int, isLeader bool, state gameState, track map[int]*checkpoint) bool {
+// Strategies the bot can play. Locally the CSB_STRATEGY environment
+// variable overrides the one submitted, to compare them against each other.
+const (
+	strategyHeuristic = "heuristic"
+	strategyMCTS      = "mcts"
+	strategyAnnealing = "annealing"
+	strategyNeural    = "neural"
+)
+
+var strategy = strategyHeuristic
+
+// turnBudget is the time we allow ourselves per turn, safely below the 75ms
+// the referee gives.
+const turnBudget = 60 * time.Millisecond
+
+// searchRand is the randomness of the searches, seeded so a game can be
+// replayed.
+var searchRand = rand.New(rand.NewSource(1))
+
+// playTurn decides the commands of both our pods with the configured
+// strategy.
+func playTurn(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+	return playStrategy(strategy, policyNet, state, track, deadline)
+}
+
+// playStrategy decides the commands of both our pods with strategy s, the
+// neural one driven by net. The first turn is always played by the
+// heuristics, the search doesn't know that pods can turn freely on it.
+func playStrategy(s string, net *network, state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+	leaderId := determineLeader(state.players)
+	var commands [2]command
+	if s == strategyMCTS && !state.first {
+		commands = mctsMove(*state, track, leaderId, deadline)
+	} else if s == strategyAnnealing && !state.first {
+		commands = annealMove(state, track, leaderId, deadline)
+	} else if s == strategyNeural && !state.first {
+		commands = net.move(*state, track, leaderId)
+	} else {
+		commands = heuristicMove(state, track, leaderId, deadline)
+	}
+	for i := range commands {
+		commands[i] = shielded(state.players[i], commands[i])
+	}
+	if !state.first && (commands[0].boost || commands[1].boost) {
+		state.usedboost = true
+	}
+	state.first = false
+	return commands
+}
+
+// played records the commands our pods played this turn, as the game took
+// them: a SHIELD locks the pod, and next turn they tell how ours moved.
+func (state *gameState) played(commands [2]command) {
+	for i, cmd := range commands {
+		if cmd.shield {
+			state.players[i].shield = shieldTurns + 1
+		}
+	}
+	state.commands, state.commandsKnown = commands, true
+}
+
+// shielded is cmd as the game plays it for g: a pod still shielded can
+// only turn, so it doesn't thrust, nor waste the boost.
+func shielded(g gamer, cmd command) command {
+	if g.shield > 0 && !cmd.shield {
+		cmd.thrust, cmd.boost = 0, false
+	}
+	return cmd
+}
+
+func heuristicMove(state *gameState, track map[int]*checkpoint, leaderId int, deadline time.Time) [2]command {
+	var commands [2]command
+	for playerId := 0; playerId < 2; playerId++ {
+		isLeader := playerId == leaderId
+
+		commands[playerId], state.modes[playerId] = movePlayer(playerId, isLeader, *state, track, deadline)
+	}
+	coordinated := coordinateTeam(*state, track, leaderId, commands)
+	if coordinated != commands {
+		state.modes[1-leaderId] = modeTeam
+	}
+	return coordinated
+}
+
+// The ways movePlayer moves a pod, and modeTeam when coordinateTeam changed
+// the blocker's move.
+const (
+	modeNormal  = "normal"
+	modeAggro   = "aggro"
+	modeDefense = "defense"
+	modeDuel    = "duel"
+	modeTeam    = "team"
+)
+
+// movePlayer decides the command of one of our pods, and returns the mode it
+// moved the pod in.
+func movePlayer(playerId int, isLeader bool, state gameState, track map[int]*checkpoint, deadline time.Time) (command, string) {
 	player := state.players[playerId]
 	checkpoint := track[player.nextCheckPointId]
 	var partner gamer
@@ -193,8 +512,8 @@ func movePlayer(playerId int, isLeader bool, state gameState, track map[int]*che
 	toLongDistanceAimV := NewSmartVectorCartesian(float64(checkpoint.longDistanceAimpoint.x-x), float64(checkpoint.longDistanceAimpoint.y-y))
 
 	fmt.Fprintf(os.Stderr, "player: %+v\n", player)
-	nextCheckpointAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees) - player.angle)
-	nextCheckpointDist := int(targetV.length)
+	nextCheckpointAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees()) - player.angle)
+	nextCheckpointDist := int(targetV.length())
 
 	var thrust int
 	var useShield bool
@@ -203,20 +522,34 @@ func movePlayer(playerId int, isLeader bool, state gameState, track map[int]*che
 	thirdLap := partner.currentlap >= 3 || opponents[0].currentlap >= 3 || opponents[1].currentlap >= 3
 
 	neverAgressive := false // true value only for debugging
+	var mode string
 	if isLeader || neverAgressive || firstStretch {
+		mode = modeNormal
 		targetV, thrust = normalMove(player, toCheckpointV, toLongDistanceAimV, toNextAimpointV)
-		useShield = nextCheckpointDist < 1000 && (toOpponent0V.length < 900 || toOpponent1V.length < 900)
+		useShield = nextCheckpointDist < 1000 && (toOpponent0V.length() < 900 || toOpponent1V.length() < 900)
 		if useShield {
 			useShield = shouldRarelyUseShield(player, opponents, false)
 		}
-		useBoost = (state.first && isLeader) || (!state.usedboost && nextCheckpointDist > 5500 && nextCheckpointAngle < 3 && nextCheckpointAngle > -3 && toOpponent0V.length > 2000 && toOpponent1V.length > 2000)
+		useBoost = (state.first && isLeader) || (!state.usedboost && nextCheckpointDist > 5500 && nextCheckpointAngle < 3 && nextCheckpointAngle > -3 && toOpponent0V.length() > 2000 && toOpponent1V.length() > 2000)
 	} else if opponentLeads(state.players, state.opponents) || thirdLap {
-		targetV, thrust = fullDefenseMode(player, partner, track, opponents)
+		// the duel gets half of what is left, for our other pod and
+		// coordinateTeam to have the rest
+		if cmd, ok := duelMove(state, track, playerId, time.Now().Add(time.Until(deadline)/2)); ok {
+			return shielded(player, cmd), modeDuel
+		}
+		mode = modeDefense
+		targetV, thrust = fullDefenseMode(player, track, opponents)
 		useShield = shouldUseShield(player, opponents, useShield)
 	} else {
+		mode = modeAggro
 		targetV, thrust = aggroMove(player, nextCheckpointAngle, targetV, toLongDistanceAimV, toNextAimpointV, nextCheckpointDist, toOpponent0V, toOpponent1V)
 		useShield = shouldUseShield(player, opponents, useShield)
 	}
+	if player.shield > 1 {
+		// still shielded through the collisions we look out for, shielding
+		// again would only keep the thrust off longer
+		useShield = false
+	}
 	fmt.Fprintf(os.Stderr, "targetV: %v\n", targetV)
 
 	// You have to output the target position
@@ -226,13 +559,84 @@ func movePlayer(playerId int, isLeader bool, state gameState, track map[int]*che
 	fmt.Fprintf(os.Stderr, "usedboost: %t\n", state.usedboost)
 	if useBoost {
 		fmt.Fprintf(os.Stderr, "BOOOOOOOOOOOST!!!!!!!!!!!!!!!\n")
-		fmt.Printf("%d %d BOOST\n", x+targetX, y+targetY)
-	} else if useShield {
-		fmt.Printf("%d %d SHIELD\n", x+targetX, y+targetY)
-	} else {
-		fmt.Printf("%d %d %d\n", x+targetX, y+targetY, thrust)
 	}
-	return useBoost
+	return shielded(player, command{x: x + targetX, y: y + targetY, thrust: thrust, boost: useBoost, shield: useShield}), mode
+}
+
+const (
+	teamLookahead    = 6
+	teamRange        = 4000 // pods further apart than this can't meet within teamLookahead
+	blockerWeight    = 0.3
+	teamSwitchMargin = 100
+)
+
+// predictTeam plays our commands for teamLookahead turns, with the opponents
+// racing to their checkpoints, and returns the team score together with
+// whether our pods ran into each other. The runner's progress counts fully,
+// the blocker only gets credit for how close it stays to where it was going.
+func predictTeam(state gameState, track map[int]*checkpoint, runnerId int, cmds, goals [2]command) (float64, bool) {
+	pods := [4]simPod{newSimPod(state.players[0]), newSimPod(state.players[1]), newSimPod(state.opponents[0]), newSimPod(state.opponents[1])}
+	var all [4]command
+	bumped := false
+	for turn := 0; turn < teamLookahead; turn++ {
+		all[0], all[1] = cmds[0], cmds[1]
+		if turn > 0 {
+			// movePlayer decides again next turn, only the direction is held
+			for i := 0; i < 2; i++ {
+				all[i].boost, all[i].shield = false, false
+			}
+		}
+		all[2], all[3] = chaseCheckpoint(pods[2], track), chaseCheckpoint(pods[3], track)
+		if simulateTurn(pods[:], all[:], track)&pairBit(0, 1) != 0 {
+			bumped = true
+		}
+	}
+	blockerId := 1 - runnerId
+	goal := goals[blockerId]
+	blocker := pods[blockerId]
+	score := pods[runnerId].progress(track) - blockerWeight*math.Hypot(float64(goal.x)-blocker.x, float64(goal.y)-blocker.y)
+	return score, bumped
+}
+
+// blockerAlternatives are the other ways the blocker can play this turn:
+// swerve around the runner, let it pass, or deliberately push it forward.
+func blockerAlternatives(blocker, runner gamer, cmd command) []command {
+	toTargetV := NewSmartVectorCartesian(float64(cmd.x-blocker.x), float64(cmd.y-blocker.y))
+	var alternatives []command
+	for _, swerve := range []float64{30, -30} {
+		swerveV := NewSmartVectorPolar(toTargetV.length(), toTargetV.angleDegrees()+swerve)
+		swerveX, swerveY := swerveV.GetXYAsInts()
+		alternatives = append(alternatives, command{x: blocker.x + swerveX, y: blocker.y + swerveY, thrust: cmd.thrust})
+	}
+	alternatives = append(alternatives, command{x: cmd.x, y: cmd.y, thrust: 0})
+	alternatives = append(alternatives, command{x: runner.x + runner.vx, y: runner.y + runner.vy, thrust: 100})
+	return alternatives
+}
+
+// coordinateTeam looks at both our pods together: when their predicted paths
+// cross, the blocker gets out of the runner's way, or bumps into it on
+// purpose when that pushes the runner forward. Only the blocker's command is
+// changed, and only when that makes the team better off.
+func coordinateTeam(state gameState, track map[int]*checkpoint, runnerId int, cmds [2]command) [2]command {
+	blockerId := 1 - runnerId
+	runner, blocker := state.players[runnerId], state.players[blockerId]
+	if math.Hypot(float64(runner.x-blocker.x), float64(runner.y-blocker.y)) > teamRange {
+		return cmds
+	}
+	bestScore, bumped := predictTeam(state, track, runnerId, cmds, cmds)
+	best := cmds
+	for _, alternative := range blockerAlternatives(blocker, runner, cmds[blockerId]) {
+		trial := cmds
+		trial[blockerId] = alternative
+		score, trialBumped := predictTeam(state, track, runnerId, trial, cmds)
+		if score > bestScore+teamSwitchMargin {
+			bestScore, bumped, best = score, trialBumped, trial
+		}
+	}
+	if best != cmds {
+		fmt.Fprintf(os.Stderr, "TEAM: blocker %v instead of %v, bump: %t\n", best[blockerId], cmds[blockerId], bumped)
+	}
+	return best
 }
 
 func shouldUseShield(player gamer, opponents [2]gamer, useShield bool) bool {
@@ -256,11 +660,12 @@ func normalMove(player gamer, toCheckpointV, toLongDistanceAimV, toNextAimpointV
 
 	thrust := 100
 	if smartThrust < 0 {
-		checkpointDeltaAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees) - player.angle)
-		checkpointDist := toCheckpointV.length
-		if checkpointDist < 2000 {
+		checkpointDeltaAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees()) - player.angle)
+		checkpointDist := toCheckpointV.length()
+		checkpointX, checkpointY := toCheckpointV.GetXYAsInts()
+		if checkpointDist < 2000 && !onCourse(player, point{player.x + checkpointX, player.y + checkpointY}) {
 			thrust = int(100 * (checkpointDist + 100) / 2100)
-			fmt.Fprintf(os.Stderr, "distance: %f, thrust: %f\n", checkpointDist, thrust)
+			fmt.Fprintf(os.Stderr, "distance: %f, thrust: %d\n", checkpointDist, thrust)
 		}
 		if checkpointDeltaAngle > 45 || checkpointDeltaAngle < -45 {
 			thrust = 60
@@ -278,12 +683,12 @@ func aggroMove(player gamer, nextCheckpointAngle int, defaultTargetV, longDistan
 	fmt.Fprintln(os.Stderr, "AGGRO MODE")
 	aggroTargetV := defaultTargetV
 	aggressive := false
-	if defaultTargetV.length < 6000 {
-		if toOpponent0V.length*2 < defaultTargetV.length && toOpponent0V.length < aggroTargetV.length {
+	if defaultTargetV.length() < 6000 {
+		if toOpponent0V.length()*2 < defaultTargetV.length() && toOpponent0V.length() < aggroTargetV.length() {
 			aggroTargetV = toOpponent0V
 			aggressive = true
 		}
-		if toOpponent1V.length*2 < defaultTargetV.length && toOpponent1V.length < aggroTargetV.length {
+		if toOpponent1V.length()*2 < defaultTargetV.length() && toOpponent1V.length() < aggroTargetV.length() {
 			aggroTargetV = toOpponent1V
 			aggressive = true
 		}
@@ -295,7 +700,7 @@ func aggroMove(player gamer, nextCheckpointAngle int, defaultTargetV, longDistan
 		if smartThrust < 0 {
 			if nextCheckpointDist < 2000 {
 				thrust = 100 * (nextCheckpointDist + 100) / 2100
-				fmt.Fprintf(os.Stderr, "distance: %f, thrust: %f\n", nextCheckpointDist, thrust)
+				fmt.Fprintf(os.Stderr, "distance: %d, thrust: %d\n", nextCheckpointDist, thrust)
 			}
 			if nextCheckpointAngle > 90 || nextCheckpointAngle < -90 {
 				thrust = 5
@@ -308,7 +713,7 @@ func aggroMove(player gamer, nextCheckpointAngle int, defaultTargetV, longDistan
 	return aggroTargetV, thrust
 }
 
-func fullDefenseMode(player, partner gamer, track map[int]*checkpoint, opponents [2]gamer) (SmartVector, int) {
+func fullDefenseMode(player gamer, track map[int]*checkpoint, opponents [2]gamer) (SmartVector, int) {
 	fmt.Fprintln(os.Stderr, "FULL DEFENSE MODE")
 	x := player.x
 	y := player.y
@@ -322,47 +727,35 @@ func fullDefenseMode(player, partner gamer, track map[int]*checkpoint, opponents
 	opponentToTargetV := NewSmartVectorCartesian(float64(opponentCheckpoint.x-opponentLeader.x), float64(opponentCheckpoint.y-opponentLeader.x))
 	toOpponentNextTargetV := NewSmartVectorCartesian(float64(opponentNextCheckpoint.x-x), float64(opponentNextCheckpoint.y-y))
 
-	distanceToOpponent := toOpponentV.length
-	magicAngleRadians := (180 - float64(normalizeAngleDegrees(int(toOpponentV.angleDegrees-opponentToTargetV.angleDegrees)))) / 180 * math.Pi
+	distanceToOpponent := toOpponentV.length()
+	magicAngleRadians := (180 - float64(normalizeAngleDegrees(int(toOpponentV.angleDegrees()-opponentToTargetV.angleDegrees())))) / 180 * math.Pi
 	distanceToIntersection := math.Abs(distanceToOpponent / 2 * math.Tan(magicAngleRadians))
 	fmt.Fprintf(os.Stderr, "magicAngle: %f, distToOpp: %f, distToInt: %f\n", magicAngleRadians, distanceToOpponent, distanceToIntersection)
-	opponentToIntersectionV := NewSmartVectorPolar(distanceToIntersection, opponentToTargetV.angleDegrees)
+	opponentToIntersectionV := NewSmartVectorPolar(distanceToIntersection, opponentToTargetV.angleDegrees())
 	toIntersectionV := opponentToIntersectionV.addVector(toOpponentV)
 
 	aggroTargetV := toIntersectionV
-	if aggroTargetV.length > opponentToTargetV.length {
+	if aggroTargetV.length() > opponentToTargetV.length() {
 		if opponentLeader.nextCheckPointId > 0 || opponentLeader.currentlap < 3 {
 			aggroTargetV = toOpponentNextTargetV
-			if toOpponentNextTargetV.length < 1200 {
+			if toOpponentNextTargetV.length() < 1200 {
 				aggroTargetV = toOpponentV
 			}
 		} else {
 			//aggroTargetV = toOpponentTargetV
-			if toOpponentTargetV.length < 1200 {
+			if toOpponentTargetV.length() < 1200 {
 				aggroTargetV = toOpponentV
 			}
 		}
 	}
 
-	collsteps, collisionV := futureCollisionCourse(player, partner, 800, 10)
-	if collsteps > 0 && collisionV.length < aggroTargetV.length {
-		diffAngle := normalizeAngleDegrees(int(aggroTargetV.angleDegrees - collisionV.angleDegrees))
-		if diffAngle > 0 {
-			aggroTargetV = NewSmartVectorPolar(aggroTargetV.length, collisionV.angleDegrees+30)
-		} else {
-			aggroTargetV = NewSmartVectorPolar(aggroTargetV.length, collisionV.angleDegrees-30)
-		}
-		fmt.Fprintf(os.Stderr, "COLLISIONPATH  V: %v\n", collisionV)
-		fmt.Fprintf(os.Stderr, "COLLISIONAVOID V: %v\n", aggroTargetV)
-	}
-
-	if math.Abs(float64(normalizeAngleDegrees(int(aggroTargetV.angleDegrees - currentSpeedV.angleDegrees)))) < 40 {
+	if math.Abs(float64(normalizeAngleDegrees(int(aggroTargetV.angleDegrees() - currentSpeedV.angleDegrees())))) < 40 {
 		aggroTargetV = smartDirectionChangeVector(aggroTargetV, currentSpeedV)
 	}
 
 	thrust := 100
 
-	targetAngle := normalizeAngleDegrees(int(aggroTargetV.angleDegrees) - player.angle)
+	targetAngle := normalizeAngleDegrees(int(aggroTargetV.angleDegrees()) - player.angle)
 	if targetAngle > 90 || targetAngle < -90 {
 		thrust = 1
 	} else if targetAngle > 60 || targetAngle < -60 {
@@ -377,39 +770,25 @@ func getDirectionSmartVector(player gamer, toCheckpointV, longDistanceAimV, toNe
 	smartThrust := -1
 	smartDirectionV := toCheckpointV
 	currentSpeedV := player.currentSpeedV()
-	checkpointAngle := toCheckpointV.angleDegrees
-	checkpointDeltaAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees) - player.angle)
-	viabilityAngle := normalizeAngleDegrees(int(longDistanceAimV.angleDegrees - toCheckpointV.angleDegrees))
-	playerAngleVsNextAimpointAngle := normalizeAngleDegrees(player.angle - int(toNextAimpointV.angleDegrees))
-	turnRounds := math.Abs(float64(playerAngleVsNextAimpointAngle / 18))
-	roundsToTargetCurrentSpeedWFriction, distWFriction := toTargetGivenSpeedWFriction(toCheckpointV.length, currentSpeedV.length)
-	//roundsToTargetCurrentSpeed := toCheckpointV.length / currentSpeedV.length
-	//dist := toCheckpointV.length
-	predictedPathV := NewSmartVectorPolar(float64(distWFriction), currentSpeedV.angleDegrees)
-	willProbablyHit := (toCheckpointV.subtractVector(predictedPathV)).length < 500
-	fmt.Fprintf(os.Stderr, "RTTCSWF: %f, TRDS: %f, WPH: %t\n", roundsToTargetCurrentSpeedWFriction, turnRounds, willProbablyHit)
-	fmt.Fprintf(os.Stderr, "predictedPathV: %v\n", predictedPathV)
+	checkpointAngle := toCheckpointV.angleDegrees()
+	checkpointDeltaAngle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees()) - player.angle)
+	viabilityAngle := normalizeAngleDegrees(int(longDistanceAimV.angleDegrees() - toCheckpointV.angleDegrees()))
+	checkpointX, checkpointY := toCheckpointV.GetXYAsInts()
+	nextX, nextY := toNextAimpointV.GetXYAsInts()
+	cutThrust, hitTurn := cutTheCurve(player, point{player.x + checkpointX, player.y + checkpointY}, point{player.x + nextX, player.y + nextY})
+	fmt.Fprintf(os.Stderr, "cutThrust: %d, hitTurn: %d\n", cutThrust, hitTurn)
 	fmt.Fprintf(os.Stderr, "toCheckpointV: %v\n", toCheckpointV)
 	fmt.Fprintf(os.Stderr, "currentSpeedV: %v\n", currentSpeedV)
-	if math.Abs(float64(viabilityAngle)) < 45 && toCheckpointV.length > 5500 {
+	if math.Abs(float64(viabilityAngle)) < 45 && toCheckpointV.length() > 5500 {
 		smartDirectionV = longDistanceAimV
 		fmt.Fprintf(os.Stderr, "USING SMARTDIRECTION: %+v\n", smartDirectionV)
-    } else if roundsToTargetCurrentSpeedWFriction >= 1 && turnRounds >= 1 && willProbablyHit {
-		smartDirectionV = toNextAimpointV
-		smartThrust = 0
-		potentialSpeedChangeV := NewSmartVectorPolar(100, float64(player.angle + (playerAngleVsNextAimpointAngle / int(turnRounds))))
-		newSpeedV := currentSpeedV.addVector(potentialSpeedChangeV).multiplyNumber(0.85)
-		_, newDistWFriction := toTargetGivenSpeedWFriction(toCheckpointV.length, newSpeedV.length)
-		predictedNewPathV := NewSmartVectorPolar(float64(newDistWFriction), newSpeedV.angleDegrees)
-		willProbablyHitNew := (toCheckpointV.subtractVector(predictedNewPathV)).length < 500
-		if willProbablyHitNew {
-			smartThrust = 100
-		}
-		fmt.Fprintln(os.Stderr, "Cut the curve with thrust: %d", smartThrust)
+	} else if cutThrust >= 0 {
+		smartThrust = cutThrust
+		fmt.Fprintf(os.Stderr, "Cut the curve with thrust: %d\n", smartThrust)
 		smartDirectionV = toNextAimpointV
-	} else if toCheckpointV.length > 1500 && (math.Abs(float64(checkpointDeltaAngle)) < 20 || (toCheckpointV.length < 2000 && math.Abs(float64(checkpointAngle)) < 45)) {
+	} else if toCheckpointV.length() > 1500 && (math.Abs(float64(checkpointDeltaAngle)) < 20 || (toCheckpointV.length() < 2000 && math.Abs(float64(checkpointAngle)) < 45)) {
 		smartDirectionV = smartDirectionChangeVector(toCheckpointV, currentSpeedV)
-	} else if (toCheckpointV.length < 1500) && (math.Abs(float64(checkpointDeltaAngle)) < 10) {
+	} else if (toCheckpointV.length() < 1500) && (math.Abs(float64(checkpointDeltaAngle)) < 10) {
 		fmt.Fprintln(os.Stderr, "Oh so close, target next")
 		smartDirectionV = toNextAimpointV
 	}
@@ -417,46 +796,963 @@ func getDirectionSmartVector(player gamer, toCheckpointV, longDistanceAimV, toNe
 }
 
 func smartDirectionChangeVector(targetV SmartVector, currentSpeedV SmartVector) SmartVector {
-	desiredAngle := targetV.angleDegrees
-	deltaAngle := normalizeAngleDegrees(int(desiredAngle - currentSpeedV.angleDegrees))
-	fmt.Fprintf(os.Stderr, "deltaAngle: %f, lastMoveV.angleDegrees: %f\n", deltaAngle, currentSpeedV.angleDegrees)
+	desiredAngle := targetV.angleDegrees()
+	deltaAngle := normalizeAngleDegrees(int(desiredAngle - currentSpeedV.angleDegrees()))
+	fmt.Fprintf(os.Stderr, "deltaAngle: %d, lastMoveV.angleDegrees(): %f\n", deltaAngle, currentSpeedV.angleDegrees())
 	newTargetAngle := desiredAngle + (float64(deltaAngle))
-	smartDirectionV := NewSmartVectorPolar(targetV.length, newTargetAngle)
+	smartDirectionV := NewSmartVectorPolar(targetV.length(), newTargetAngle)
 	fmt.Fprintf(os.Stderr, "desiredAngle: %f, newTargetAngle: %f\n", desiredAngle, newTargetAngle)
 	fmt.Fprintf(os.Stderr, "smartDirectionV.x: %d, smartDirectionV.y: %d\n", int(smartDirectionV.x), int(smartDirectionV.y))
 	return smartDirectionV
 }
 
-func toTargetGivenSpeedWFriction(targetLength, speedLength float64) (float64, float64) {
-	roundsToTarget := 0
-	distance := 0.0
-	for round := 1; round < 10; round++ {
-		distance = distance + speedLength * math.Pow(0.85, float64(round))
-		if (distance >= targetLength) {
-			roundsToTarget = round
-			return float64(roundsToTarget), distance
+// Referee physics, used to predict where pods end up a few turns ahead.
+const (
+	podRadius          = 400.0
+	checkpointRadius   = 600.0
+	maxRotation        = 18.0
+	frictionFactor     = 0.85
+	boostThrust        = 650.0
+	shieldMass         = 10.0
+	shieldTurns        = 3 // turns after a SHIELD the pod can't thrust, keeping shieldMass
+	minImpulse         = 120.0
+	checkpointProgress = 30000.0 // worth more than any distance on the map
+)
+
+// simPod is a pod as the referee sees it during a turn: unlike gamer it keeps
+// fractional positions and speeds until the end of the turn.
+type simPod struct {
+	x, y, vx, vy     float64
+	angle            Angle
+	mass             float64
+	shield           int // turns from this one the pod is still shielded for
+	nextCheckPointId int
+	passed           int // checkpoints passed since the simulation started
+}
+
+func newSimPod(g gamer) simPod {
+	return simPod{
+		x:                float64(g.x),
+		y:                float64(g.y),
+		vx:               float64(g.vx),
+		vy:               float64(g.vy),
+		angle:            AngleDegrees(float64(g.angle)),
+		mass:             1,
+		shield:           g.shield,
+		nextCheckPointId: g.nextCheckPointId,
+	}
+}
+
+// rotate turns the pod towards the target, at most maxRotation degrees.
+func (p *simPod) rotate(targetX, targetY float64) {
+	if targetX == p.x && targetY == p.y {
+		return
+	}
+	p.angle = p.angle.Towards(AngleRadians(math.Atan2(targetY-p.y, targetX-p.x)), maxRotation)
+}
+
+func (p *simPod) accelerate(thrust float64) {
+	sin, cos := math.Sincos(p.angle.Radians())
+	p.vx += cos * thrust
+	p.vy += sin * thrust
+}
+
+// apply starts the turn with the pod's command. A shielded pod turns but
+// doesn't thrust, for the turn of the SHIELD and shieldTurns after it.
+func (p *simPod) apply(cmd command) {
+	p.rotate(float64(cmd.x), float64(cmd.y))
+	p.mass = 1
+	if cmd.shield {
+		p.shield = shieldTurns + 1
+	}
+	if p.shield > 0 {
+		p.mass = shieldMass
+	} else if cmd.boost {
+		p.accelerate(boostThrust)
+	} else {
+		p.accelerate(float64(cmd.thrust))
+	}
+}
+
+// collisionTime returns when, within the next limit fraction of a turn, the
+// two pods touch, or -1 if they don't.
+func (p *simPod) collisionTime(o *simPod, limit float64) float64 {
+	dx, dy := o.x-p.x, o.y-p.y
+	dvx, dvy := o.vx-p.vx, o.vy-p.vy
+	a := dvx*dvx + dvy*dvy
+	b := 2 * (dx*dvx + dy*dvy)
+	if a == 0 || b >= 0 {
+		return -1 // not moving towards each other
+	}
+	c := dx*dx + dy*dy - 4*podRadius*podRadius
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return -1
+	}
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	if t < 0 {
+		t = 0
+	}
+	if t >= limit {
+		return -1
+	}
+	return t
+}
+
+// bounce is the referee's elastic collision, with an impulse of at least
+// minImpulse.
+func bounce(a, b *simPod) {
+	nx, ny := b.x-a.x, b.y-a.y
+	nn := nx*nx + ny*ny
+	if nn == 0 {
+		return
+	}
+	product := nx*(a.vx-b.vx) + ny*(a.vy-b.vy)
+	coefficient := nn * (a.mass + b.mass) / (a.mass * b.mass)
+	fx, fy := nx*product/coefficient, ny*product/coefficient
+	a.vx, a.vy = a.vx-fx/a.mass, a.vy-fy/a.mass
+	b.vx, b.vy = b.vx+fx/b.mass, b.vy+fy/b.mass
+	impulse := math.Sqrt(fx*fx + fy*fy)
+	if impulse > 0 && impulse < minImpulse {
+		fx, fy = fx*minImpulse/impulse, fy*minImpulse/impulse
+	}
+	a.vx, a.vy = a.vx-fx/a.mass, a.vy-fy/a.mass
+	b.vx, b.vy = b.vx+fx/b.mass, b.vy+fy/b.mass
+}
+
+// crosses tells whether the pod's center comes within checkpointRadius of cp
+// while it moves for the next step fraction of the turn.
+func (p *simPod) crosses(cp point, step float64) bool {
+	dx, dy := p.vx*step, p.vy*step
+	fx, fy := float64(cp.x)-p.x, float64(cp.y)-p.y
+	t := 0.0
+	if segment := dx*dx + dy*dy; segment > 0 {
+		t = (fx*dx + fy*dy) / segment
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+	}
+	cx, cy := fx-dx*t, fy-dy*t
+	return cx*cx+cy*cy < checkpointRadius*checkpointRadius
+}
+
+// move advances the pod for step fraction of the turn, passing its next
+// checkpoint if its path crosses it on the way.
+func (p *simPod) move(step float64, track map[int]*checkpoint) {
+	if p.crosses(track[p.nextCheckPointId].center, step) {
+		p.nextCheckPointId = (p.nextCheckPointId + 1) % len(track)
+		p.passed++
+	}
+	p.x += p.vx * step
+	p.y += p.vy * step
+}
+
+func (p *simPod) endTurn() {
+	p.x, p.y = math.Round(p.x), math.Round(p.y)
+	p.vx, p.vy = math.Trunc(p.vx*frictionFactor), math.Trunc(p.vy*frictionFactor)
+	p.angle = p.angle.Round()
+	if p.shield > 0 {
+		p.shield--
+	}
+}
+
+// progress is how far the pod got since the simulation started.
+func (p simPod) progress(track map[int]*checkpoint) float64 {
+	cp := track[p.nextCheckPointId].center
+	return float64(p.passed)*checkpointProgress - math.Hypot(float64(cp.x)-p.x, float64(cp.y)-p.y)
+}
+
+func pairBit(i, j int) uint {
+	return 1 << uint(i*4+j)
+}
+
+// simulateTurn plays one turn for the pods: apply the commands, move while
+// resolving collisions in the order they happen, then friction and rounding.
+// The returned mask has pairBit(i, j) set for every pair of pods that collided.
+func simulateTurn(pods []simPod, cmds []command, track map[int]*checkpoint) uint {
+	for i := range pods {
+		pods[i].apply(cmds[i])
+	}
+	var collisions uint
+	t := 0.0
+	for bounces := 0; t < 1 && bounces < 16; bounces++ {
+		step, first, second := 1-t, -1, -1
+		for i := range pods {
+			for j := i + 1; j < len(pods); j++ {
+				if ct := pods[i].collisionTime(&pods[j], step); ct >= 0 {
+					step, first, second = ct, i, j
+				}
+			}
+		}
+		for i := range pods {
+			pods[i].move(step, track)
+		}
+		t += step
+		if first < 0 {
+			break
+		}
+		bounce(&pods[first], &pods[second])
+		collisions |= pairBit(first, second)
+	}
+	for i := range pods {
+		if t < 1 {
+			pods[i].move(1-t, track)
+		}
+		pods[i].endTurn()
+	}
+	return collisions
+}
+
+const crossingLookahead = 6
+
+// checkpointHitTurn plays the commands for the pod on its own and returns the
+// turn, counting from 1, on which its path crosses the checkpoint at cp; 0
+// when it doesn't within the commands given.
+func checkpointHitTurn(p simPod, cmds []command, cp point) int {
+	for turn, cmd := range cmds {
+		p.apply(cmd)
+		if p.crosses(cp, 1) {
+			return turn + 1
+		}
+		p.x, p.y = p.x+p.vx, p.y+p.vy
+		p.endTurn()
+	}
+	return 0
+}
+
+func holdCommand(cmd command) [crossingLookahead]command {
+	var cmds [crossingLookahead]command
+	for i := range cmds {
+		cmds[i] = cmd
+	}
+	return cmds
+}
+
+// onCourse tells whether the pod crosses the checkpoint at cp when it keeps
+// flying at it at full thrust.
+func onCourse(player gamer, cp point) bool {
+	cmds := holdCommand(command{x: cp.x, y: cp.y, thrust: 100})
+	return checkpointHitTurn(newSimPod(player), cmds[:], cp) > 0
+}
+
+// cutTheCurve returns the highest thrust with which the pod can already head
+// for the aimpoint after the checkpoint at cp and still cross it, together
+// with the turn it crosses on. Less than full thrust is only worth it while
+// the pod is still turning towards next. Returns -1 when it's too early.
+func cutTheCurve(player gamer, cp, next point) (int, int) {
+	toNextDegrees := math.Atan2(float64(next.y-player.y), float64(next.x-player.x)) * 180 / math.Pi
+	turning := math.Abs(AngleDegrees(float64(player.angle)).Turn(AngleDegrees(toNextDegrees)))
+	turnsToFace := int(math.Ceil(turning / maxRotation))
+	for _, thrust := range []int{100, 50, 0} {
+		cmds := holdCommand(command{x: next.x, y: next.y, thrust: thrust})
+		turn := checkpointHitTurn(newSimPod(player), cmds[:], cp)
+		if turn > 0 && (thrust == 100 || turn <= turnsToFace) {
+			return thrust, turn
+		}
+	}
+	return -1, 0
+}
+
+// chaseCheckpoint is how we expect a pod we don't control to move: straight
+// for its next checkpoint at full thrust.
+func chaseCheckpoint(p simPod, track map[int]*checkpoint) command {
+	cp := track[p.nextCheckPointId].center
+	return command{x: cp.x, y: cp.y, thrust: 100}
+}
+
+// defaultPolicy is normalMove boiled down for rollouts: head for the next
+// checkpoint, turn to the one after as soon as the drift carries the pod
+// through, and ease off the thrust when the target is off the nose.
+func defaultPolicy(p simPod, track map[int]*checkpoint) command {
+	cp := track[p.nextCheckPointId]
+	target := cp.center
+	if p.crosses(target, 2) {
+		target = cp.nextAimpoint
+	}
+	dx, dy := float64(target.x)-p.x, float64(target.y)-p.y
+	delta := math.Abs(p.angle.Turn(AngleRadians(math.Atan2(dy, dx))))
+	thrust := 100
+	if delta > 90 {
+		thrust = 1
+	} else if delta > 45 {
+		thrust = 60
+	} else if distance := math.Hypot(dx, dy); distance < 2000 {
+		thrust = int(100 * (distance + 100) / 2100)
+	}
+	return command{x: target.x, y: target.y, thrust: thrust}
+}
+
+// interceptPolicy is the blocker's default: wait at the opponent's next
+// checkpoint, and go for the opponent once it comes close.
+func interceptPolicy(p, opponent simPod, track map[int]*checkpoint) command {
+	target := track[opponent.nextCheckPointId].center
+	if math.Hypot(opponent.x-p.x, opponent.y-p.y) < 2500 {
+		target = point{int(opponent.x + opponent.vx), int(opponent.y + opponent.vy)}
+	}
+	return command{x: target.x, y: target.y, thrust: 100}
+}
+
+// podAction is a command relative to the pod's heading, which is what the
+// searches work with.
+type podAction struct {
+	rotation float64
+	thrust   int
+	shield   bool
+	boost    bool
+}
+
+func (a podAction) command(p simPod) command {
+	sin, cos := math.Sincos(p.angle.Add(a.rotation).Radians())
+	return command{
+		x:      int(math.Round(p.x + 10000*cos)),
+		y:      int(math.Round(p.y + 10000*sin)),
+		thrust: a.thrust,
+		shield: a.shield,
+		boost:  a.boost,
+	}
+}
+
+// race is a simulated position of all four pods, our pods first, together
+// with who the search plays for and against.
+type race struct {
+	pods         [4]simPod
+	runnerId     int
+	oppLeaderId  int
+	boostAllowed bool
+}
+
+func newRace(state gameState, runnerId int) race {
+	return race{
+		pods:         [4]simPod{newSimPod(state.players[0]), newSimPod(state.players[1]), newSimPod(state.opponents[0]), newSimPod(state.opponents[1])},
+		runnerId:     runnerId,
+		oppLeaderId:  determineLeader(state.opponents),
+		boostAllowed: !state.usedboost,
+	}
+}
+
+// defaultCommand is what pod i does when the search doesn't decide for it.
+func (r *race) defaultCommand(i int, track map[int]*checkpoint) command {
+	if i == 1-r.runnerId {
+		return interceptPolicy(r.pods[i], r.pods[2+r.oppLeaderId], track)
+	}
+	return defaultPolicy(r.pods[i], track)
+}
+
+// play plays one turn with the given commands for our pods, the opponents
+// playing their default.
+func (r *race) play(ours [2]command, track map[int]*checkpoint) {
+	cmds := [4]command{ours[0], ours[1], r.defaultCommand(2, track), r.defaultCommand(3, track)}
+	simulateTurn(r.pods[:], cmds[:], track)
+}
+
+// step plays one turn in which our pod i does action a and everybody else
+// plays their default.
+func (r *race) step(i int, a podAction, track map[int]*checkpoint) {
+	var ours [2]command
+	for j := range ours {
+		if j == i {
+			ours[j] = a.command(r.pods[j])
+		} else {
+			ours[j] = r.defaultCommand(j, track)
+		}
+	}
+	r.play(ours, track)
+}
+
+// score is how good the race looks for us: our runner's progress against
+// the opponent leader's, and our blocker standing at the checkpoint the
+// opponent leader goes for.
+func (r *race) score(track map[int]*checkpoint) float64 {
+	blocker := r.pods[1-r.runnerId]
+	opponent := r.pods[2+r.oppLeaderId]
+	cp := track[opponent.nextCheckPointId].center
+	guard := math.Hypot(float64(cp.x)-blocker.x, float64(cp.y)-blocker.y)
+	return r.pods[r.runnerId].progress(track) - opponent.progress(track) - blockerWeight*guard
+}
+
+// The actions the tree search chooses from for a pod each turn.
+var mctsActions = []podAction{
+	{-18, 100, false, false}, {-9, 100, false, false}, {0, 100, false, false}, {9, 100, false, false}, {18, 100, false, false},
+	{-18, 50, false, false}, {0, 50, false, false}, {18, 50, false, false},
+	{-18, 0, false, false}, {0, 0, false, false}, {18, 0, false, false},
+	{0, 0, true, false},
+	{0, 0, false, true},
+}
+
+const (
+	mctsDepth       = 4 // turns decided in the tree
+	mctsHorizon     = 8 // turns simulated in total, the rest by the default policies
+	mctsExploration = 1.0
+	mctsScale       = 2000 // score difference that counts as a clear win
+)
+
+type mctsNode struct {
+	children []*mctsNode
+	visits   int
+	value    float64
+}
+
+// pick chooses the child to descend into: untried actions first, then UCB1.
+func (n *mctsNode) pick(boostAllowed bool) int {
+	if n.children == nil {
+		n.children = make([]*mctsNode, len(mctsActions))
+	}
+	best, bestValue := -1, math.Inf(-1)
+	offset := searchRand.Intn(len(mctsActions))
+	for k := range mctsActions {
+		a := (k + offset) % len(mctsActions)
+		if mctsActions[a].boost && !boostAllowed {
+			continue
+		}
+		child := n.children[a]
+		if child == nil {
+			return a
+		}
+		value := child.value/float64(child.visits) + mctsExploration*math.Sqrt(math.Log(float64(n.visits))/float64(child.visits))
+		if value > bestValue {
+			best, bestValue = a, value
+		}
+	}
+	return best
+}
+
+// mctsSearch runs a Monte Carlo tree search for our pod i until the
+// deadline, with all other pods playing their default policies, and returns
+// the action tried most.
+func mctsSearch(root race, i int, track map[int]*checkpoint, deadline time.Time) (podAction, int) {
+	return mctsSearchUntil(root, i, track, func(iterations int) bool {
+		return iterations%16 == 0 && !time.Now().Before(deadline)
+	})
+}
+
+// mctsSearchUntil is mctsSearch stopping once done says so, asked before
+// each iteration.
+func mctsSearchUntil(root race, i int, track map[int]*checkpoint, done func(iterations int) bool) (podAction, int) {
+	tree := &mctsNode{}
+	baseline := root
+	for turn := 0; turn < mctsHorizon; turn++ {
+		baseline.step(-1, podAction{}, track)
+	}
+	baseScore := baseline.score(track)
+	path := make([]*mctsNode, 0, mctsDepth+1)
+	iterations := 0
+	for ; !done(iterations); iterations++ {
+		r := root
+		node := tree
+		path = append(path[:0], node)
+		turn := 0
+		for turn < mctsDepth {
+			a := node.pick(r.boostAllowed)
+			if mctsActions[a].boost {
+				r.boostAllowed = false
+			}
+			r.step(i, mctsActions[a], track)
+			turn++
+			if node.children[a] == nil {
+				node.children[a] = &mctsNode{}
+				path = append(path, node.children[a])
+				break
+			}
+			node = node.children[a]
+			path = append(path, node)
+		}
+		for ; turn < mctsHorizon; turn++ {
+			r.step(-1, podAction{}, track)
+		}
+		value := (r.score(track) - baseScore) / mctsScale
+		for _, n := range path {
+			n.visits++
+			n.value += value
+		}
+	}
+	best, bestVisits := 2, -1
+	for a, child := range tree.children {
+		if child != nil && child.visits > bestVisits {
+			best, bestVisits = a, child.visits
+		}
+	}
+	return mctsActions[best], iterations
+}
+
+// mctsMove searches for both our pods, one after the other, each in half of
+// the remaining time.
+func mctsMove(state gameState, track map[int]*checkpoint, runnerId int, deadline time.Time) [2]command {
+	root := newRace(state, runnerId)
+	var commands [2]command
+	for i := 0; i < 2; i++ {
+		podDeadline := deadline
+		if i == 0 {
+			podDeadline = time.Now().Add(time.Until(deadline) / 2)
+		}
+		action, iterations := mctsSearch(root, i, track, podDeadline)
+		commands[i] = action.command(root.pods[i])
+		fmt.Fprintf(os.Stderr, "MCTS pod %d: %+v after %d iterations\n", i, action, iterations)
+	}
+	return commands
+}
+
+const annealHorizon = 6
+
+// annealPlan is what both our pods do for the next annealHorizon turns.
+type annealPlan [annealHorizon][2]podAction
+
+// mutation is a way to change a plan a little, picked with the given weight.
+type mutation struct {
+	weight float64
+	apply  func(plan *annealPlan, turn, pod int)
+}
+
+// annealConfig is the temperature schedule and the mutations the annealing
+// uses. The temperature cools exponentially from start to end over the turn,
+// or linearly when linear is set.
+type annealConfig struct {
+	startTemperature float64
+	endTemperature   float64
+	linear           bool
+	mutations        []mutation
+}
+
+var annealing = annealConfig{
+	startTemperature: 400,
+	endTemperature:   2,
+	mutations: []mutation{
+		{4, nudgeRotation},
+		{3, nudgeThrust},
+		{2, resampleAction},
+		{0.5, toggleShield},
+		{0.5, toggleBoost},
+	},
+}
+
+func (c annealConfig) temperature(progress float64) float64 {
+	if c.linear {
+		return c.startTemperature + (c.endTemperature-c.startTemperature)*progress
+	}
+	return c.startTemperature * math.Pow(c.endTemperature/c.startTemperature, progress)
+}
+
+func (c annealConfig) mutate(plan *annealPlan) {
+	total := 0.0
+	for _, m := range c.mutations {
+		total += m.weight
+	}
+	pick := searchRand.Float64() * total
+	for _, m := range c.mutations {
+		if pick -= m.weight; pick < 0 {
+			m.apply(plan, searchRand.Intn(annealHorizon), searchRand.Intn(2))
+			return
+		}
+	}
+}
+
+func nudgeRotation(plan *annealPlan, turn, pod int) {
+	a := &plan[turn][pod]
+	a.rotation = math.Max(-maxRotation, math.Min(maxRotation, a.rotation+searchRand.Float64()*12-6))
+}
+
+func nudgeThrust(plan *annealPlan, turn, pod int) {
+	a := &plan[turn][pod]
+	a.thrust += searchRand.Intn(61) - 30
+	if a.thrust < 0 {
+		a.thrust = 0
+	} else if a.thrust > 100 {
+		a.thrust = 100
+	}
+}
+
+func resampleAction(plan *annealPlan, turn, pod int) {
+	plan[turn][pod] = podAction{rotation: searchRand.Float64()*2*maxRotation - maxRotation, thrust: searchRand.Intn(101)}
+}
+
+func toggleShield(plan *annealPlan, turn, pod int) {
+	plan[turn][pod].shield = !plan[turn][pod].shield
+}
+
+func toggleBoost(plan *annealPlan, turn, pod int) {
+	plan[turn][pod].boost = !plan[turn][pod].boost
+}
+
+// legal drops a boost the team no longer has.
+func (r *race) legal(a podAction) podAction {
+	if a.boost {
+		if !r.boostAllowed {
+			a.boost = false
+			a.thrust = 100
+		}
+		r.boostAllowed = false
+	}
+	return a
+}
+
+func (r *race) playActions(actions [2]podAction, track map[int]*checkpoint) {
+	var ours [2]command
+	for i, a := range actions {
+		ours[i] = r.legal(a).command(r.pods[i])
+	}
+	r.play(ours, track)
+}
+
+func evaluatePlan(root race, plan *annealPlan, track map[int]*checkpoint) float64 {
+	for turn := range plan {
+		root.playActions(plan[turn], track)
+	}
+	return root.score(track)
+}
+
+// actionFor is the action that comes closest to the command.
+func actionFor(p simPod, cmd command) podAction {
+	rotation := p.angle.Turn(AngleRadians(math.Atan2(float64(cmd.y)-p.y, float64(cmd.x)-p.x)))
+	return podAction{rotation: math.Max(-maxRotation, math.Min(maxRotation, rotation)), thrust: cmd.thrust}
+}
+
+// defaultPlan is the plan of both our pods playing their default policies.
+func defaultPlan(root race, track map[int]*checkpoint) annealPlan {
+	var plan annealPlan
+	for turn := range plan {
+		for i := 0; i < 2; i++ {
+			plan[turn][i] = actionFor(root.pods[i], root.defaultCommand(i, track))
+		}
+		root.playActions(plan[turn], track)
+	}
+	return plan
+}
+
+// shifted is the plan one turn later: the first turn was played, the last
+// one repeats what came before it.
+func (plan annealPlan) shifted() annealPlan {
+	var next annealPlan
+	copy(next[:], plan[1:])
+	next[annealHorizon-1] = plan[annealHorizon-1]
+	for i := range next[annealHorizon-1] {
+		next[annealHorizon-1][i].shield, next[annealHorizon-1][i].boost = false, false
+	}
+	return next
+}
+
+// annealMove improves a plan for both our pods by simulated annealing until
+// the deadline, starting from last turn's plan, shifted by the turn played
+// since, or from the default policies, whichever scores better.
+func annealMove(state *gameState, track map[int]*checkpoint, runnerId int, deadline time.Time) [2]command {
+	root := newRace(*state, runnerId)
+	current := defaultPlan(root, track)
+	currentScore := evaluatePlan(root, &current, track)
+	if state.plan != nil {
+		warm := state.plan.shifted()
+		if score := evaluatePlan(root, &warm, track); score > currentScore {
+			current, currentScore = warm, score
+		}
+	}
+	best, bestScore := current, currentScore
+	start, budget := time.Now(), time.Until(deadline)
+	temperature := annealing.startTemperature
+	iterations := 0
+	for ; ; iterations++ {
+		if iterations%32 == 0 {
+			progress := float64(time.Since(start)) / float64(budget)
+			if budget <= 0 || progress >= 1 {
+				break
+			}
+			temperature = annealing.temperature(progress)
+		}
+		candidate := current
+		annealing.mutate(&candidate)
+		score := evaluatePlan(root, &candidate, track)
+		if score >= currentScore || searchRand.Float64() < math.Exp((score-currentScore)/temperature) {
+			current, currentScore = candidate, score
+			if score > bestScore {
+				best, bestScore = candidate, score
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "ANNEALING: %+v scores %f after %d iterations\n", best[0], bestScore, iterations)
+	state.plan = &best
+	var commands [2]command
+	for i := range commands {
+		commands[i] = root.legal(best[0][i]).command(root.pods[i])
+	}
+	return commands
+}
+
+const (
+	duelRange    = 3000 // the duel starts when the opponent runner is this close to our blocker
+	duelMaxDepth = 4
+)
+
+// The blocker plays every tree search action but BOOST, the opponent runner
+// answers with the moves a racer plausibly makes.
+var (
+	duelBlockerActions = mctsActions[:len(mctsActions)-1]
+	duelRunnerActions  = []podAction{
+		{-18, 100, false, false}, {0, 100, false, false}, {18, 100, false, false},
+		{-18, 0, false, false}, {0, 0, false, false}, {18, 0, false, false},
+		{0, 0, true, false},
+	}
+)
+
+// duel is an alpha-beta search of our blocker against the opponent runner.
+// Each turn the blocker moves first and the runner answers knowing the
+// blocker's move, so the blocker picks moves that hold up against dodging.
+// The other two pods play their defaults.
+type duel struct {
+	track    map[int]*checkpoint
+	blocker  int
+	runner   int
+	deadline time.Time
+	nodes    int
+	expired  bool
+}
+
+func (d *duel) turn(r race, b, o podAction) race {
+	var cmds [4]command
+	for i := range cmds {
+		cmds[i] = r.defaultCommand(i, d.track)
+	}
+	cmds[d.blocker] = b.command(r.pods[d.blocker])
+	cmds[d.runner] = o.command(r.pods[d.runner])
+	simulateTurn(r.pods[:], cmds[:], d.track)
+	return r
+}
+
+// duelBranch is an action after one turn, with the race it leads to and
+// its score.
+type duelBranch struct {
+	action podAction
+	next   race
+	score  float64
+}
+
+// order sorts the actions by how they score after one turn, best first for
+// the blocker (the runner answering with its default) and worst first for
+// the runner's answers to blocker action b, whose branches are those the
+// search goes down.
+func (d *duel) order(r race, b *podAction) []duelBranch {
+	var branches []duelBranch
+	if b == nil {
+		for _, a := range duelBlockerActions {
+			next := d.turn(r, a, duelRunnerActions[1])
+			branches = append(branches, duelBranch{a, next, next.score(d.track)})
+		}
+	} else {
+		for _, a := range duelRunnerActions {
+			next := d.turn(r, *b, a)
+			branches = append(branches, duelBranch{a, next, -next.score(d.track)})
 		}
 	}
-	return 0, 0
+	sort.SliceStable(branches, func(i, j int) bool { return branches[i].score > branches[j].score })
+	return branches
 }
 
-func readPlayers(state gameState, track map[int]*checkpoint) [2]gamer {
+// search returns the value of the race for the blocker when both sides play
+// their best for depth more turns, and the blocker's best first move.
+func (d *duel) search(r race, depth int, alpha, beta float64, first *podAction) (float64, podAction) {
+	blockerActions := d.order(r, nil)
+	for i := range blockerActions {
+		if first != nil && blockerActions[i].action == *first {
+			b := blockerActions[i]
+			copy(blockerActions[1:i+1], blockerActions[:i])
+			blockerActions[0] = b
+		}
+	}
+	best, bestAction := math.Inf(-1), blockerActions[0].action
+	for _, blocker := range blockerActions {
+		b := blocker.action
+		worst := math.Inf(1)
+		for _, answer := range d.order(r, &b) {
+			if d.nodes++; d.nodes%64 == 0 && time.Now().After(d.deadline) {
+				d.expired = true
+			}
+			if d.expired {
+				return best, bestAction
+			}
+			value := -answer.score
+			if depth > 1 {
+				value, _ = d.search(answer.next, depth-1, alpha, math.Min(beta, worst), nil)
+			}
+			worst = math.Min(worst, value)
+			if worst <= alpha {
+				break // the blocker has something better already
+			}
+		}
+		if worst > best {
+			best, bestAction = worst, b
+		}
+		alpha = math.Max(alpha, best)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best, bestAction
+}
+
+// duelMove searches the blocker's move against the opponent runner with
+// iterative deepening until the deadline, when the runner is close enough
+// for the blocker to get in its way.
+func duelMove(state gameState, track map[int]*checkpoint, blockerId int, deadline time.Time) (command, bool) {
+	root := newRace(state, 1-blockerId)
+	d := duel{track: track, blocker: blockerId, runner: 2 + root.oppLeaderId, deadline: deadline}
+	blocker, runner := root.pods[d.blocker], root.pods[d.runner]
+	if math.Hypot(runner.x-blocker.x, runner.y-blocker.y) > duelRange {
+		return command{}, false
+	}
+	var best *podAction
+	depth := 1
+	for ; depth <= duelMaxDepth; depth++ {
+		_, action := d.search(root, depth, math.Inf(-1), math.Inf(1), best)
+		if d.expired {
+			break
+		}
+		best = &action
+	}
+	if best == nil {
+		return command{}, false
+	}
+	fmt.Fprintf(os.Stderr, "DUEL: %+v at depth %d after %d nodes\n", *best, depth-1, d.nodes)
+	return best.command(blocker), true
+}
+
+// The pods can also be driven by a small feed-forward network: the pod's
+// situation, seen from its own frame, goes through one tanh hidden layer to
+// a steering angle and a thrust.
+const (
+	nnInputs        = 15
+	nnHidden        = 16
+	nnOutputs       = 2
+	nnWeights       = nnHidden*(nnInputs+1) + nnOutputs*(nnHidden+1)
+	nnDistanceScale = 10000.0
+	nnSpeedScale    = 1000.0
+)
+
+// nnWeightsLiteral is the network as base64 of little-endian int16 weights
+// in 1/1024ths. Each neuron has its input weights followed by its bias, the
+// hidden layer comes first. The hand-set weights only steer for the next
+// checkpoint, counter the drift and ease off when it is behind.
+var nnWeightsLiteral = "AAAAEAAAAAAAAAD8AAAAAAAAAAAAAAAAAAAAAAAAAAAAKAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABA=="
+
+// network keeps its layers' activations so that inference doesn't allocate.
+type network struct {
+	weights [nnWeights]float64
+	hidden  [nnHidden]float64
+	output  [nnOutputs]float64
+}
+
+func decodeNetwork(literal string) (*network, error) {
+	raw, err := base64.StdEncoding.DecodeString(literal)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != 2*nnWeights {
+		return nil, fmt.Errorf("network has %d weights, want %d", len(raw)/2, nnWeights)
+	}
+	n := &network{}
+	for i := range n.weights {
+		n.weights[i] = float64(int16(binary.LittleEndian.Uint16(raw[2*i:]))) / 1024
+	}
+	return n, nil
+}
+
+var policyNet = func() *network {
+	n, err := decodeNetwork(nnWeightsLiteral)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}()
+
+func (n *network) forward(in *[nnInputs]float64) *[nnOutputs]float64 {
+	w := n.weights[:]
+	for h := range n.hidden {
+		sum := w[nnInputs]
+		for i, x := range in {
+			sum += w[i] * x
+		}
+		n.hidden[h] = math.Tanh(sum)
+		w = w[nnInputs+1:]
+	}
+	for o := range n.output {
+		sum := w[nnHidden]
+		for i, x := range n.hidden {
+			sum += w[i] * x
+		}
+		n.output[o] = sum
+		w = w[nnHidden+1:]
+	}
+	return &n.output
+}
+
+// podFeatures describes a pod's situation in its own frame, x ahead and y
+// where positive rotations turn to: the next two checkpoints, its speed,
+// where the opponents are and go, and whether it is our runner.
+func podFeatures(p simPod, isRunner bool, opponents [2]simPod, track map[int]*checkpoint) [nnInputs]float64 {
+	sin, cos := math.Sincos(p.angle.Radians())
+	frame := func(x, y, scale float64) (float64, float64) {
+		return (x*cos + y*sin) / scale, (y*cos - x*sin) / scale
+	}
+	var f [nnInputs]float64
+	cp := track[p.nextCheckPointId]
+	f[0], f[1] = frame(float64(cp.center.x)-p.x, float64(cp.center.y)-p.y, nnDistanceScale)
+	f[2], f[3] = frame(float64(cp.nextAimpoint.x)-p.x, float64(cp.nextAimpoint.y)-p.y, nnDistanceScale)
+	f[4], f[5] = frame(p.vx, p.vy, nnSpeedScale)
+	for i, o := range opponents {
+		f[6+4*i], f[7+4*i] = frame(o.x-p.x, o.y-p.y, nnDistanceScale)
+		f[8+4*i], f[9+4*i] = frame(o.vx, o.vy, nnSpeedScale)
+	}
+	if isRunner {
+		f[14] = 1
+	}
+	return f
+}
+
+// act is the network's action for the pod: the first output steers within
+// the rotation limit, the second is the thrust.
+func (n *network) act(p simPod, isRunner bool, opponents [2]simPod, track map[int]*checkpoint) podAction {
+	features := podFeatures(p, isRunner, opponents, track)
+	out := n.forward(&features)
+	return podAction{
+		rotation: maxRotation * math.Tanh(out[0]),
+		thrust:   int(math.Round(100 / (1 + math.Exp(-out[1])))),
+	}
+}
+
+func (n *network) move(state gameState, track map[int]*checkpoint, runnerId int) [2]command {
+	opponents := [2]simPod{newSimPod(state.opponents[0]), newSimPod(state.opponents[1])}
+	var commands [2]command
+	for i, player := range state.players {
+		p := newSimPod(player)
+		commands[i] = n.act(p, i == runnerId, opponents, track).command(p)
+	}
+	return commands
+}
+
+func readPlayers(in io.Reader, track map[int]*checkpoint) ([2]gamer, error) {
 	var players [2]gamer
 	for i := 0; i < 2; i++ {
 		var x, y, vx, vy, angle, nextCheckPointId int
-		fmt.Scan(&x, &y, &vx, &vy, &angle, &nextCheckPointId)
-		players[i] = gamer{x, y, vx, vy, angle, nextCheckPointId, state.players[i].advancement, state.players[i].currentlap}
-		if state.players[i].nextCheckPointId != nextCheckPointId {
-			// new checkpoint
-			fmt.Fprintf(os.Stderr, "NEW nextCheckPointId %d for player %d\n", nextCheckPointId, i)
-			if nextCheckPointId == 0 {
-				players[i].currentlap = players[i].currentlap + 1
-			}
+		if _, err := fmt.Fscan(in, &x, &y, &vx, &vy, &angle, &nextCheckPointId); err != nil {
+			return players, err
+		}
+		if track[nextCheckPointId] == nil {
+			return players, fmt.Errorf("pod %d goes for checkpoint %d, which isn't on the track", i, nextCheckPointId)
 		}
-		toCheckPointV := NewSmartVectorCartesian(float64(track[nextCheckPointId].center.x-x), float64(track[nextCheckPointId].center.y-y))
-		players[i].advancement = players[i].currentlap*1000000 + players[i].nextCheckPointId*100000 - int(toCheckPointV.length)
+		players[i] = gamer{x: x, y: y, vx: vx, vy: vy, angle: angle, nextCheckPointId: nextCheckPointId}
 	}
-	return players
+	return players, nil
+}
+
+// observe is the gamer as read from this turn's input, counting its laps
+// and advancement from where it was before.
+func observe(prev gamer, x, y, vx, vy, angle, nextCheckPointId int, track map[int]*checkpoint) gamer {
+	g := gamer{x, y, vx, vy, angle, nextCheckPointId, prev.advancement, prev.currentlap, prev.shield}
+	if g.shield > 0 {
+		g.shield--
+	}
+	if prev.nextCheckPointId != nextCheckPointId && nextCheckPointId == 0 {
+		g.currentlap = g.currentlap + 1
+	}
+	toCheckPointV := NewSmartVectorCartesian(float64(track[nextCheckPointId].center.x-x), float64(track[nextCheckPointId].center.y-y))
+	g.advancement = g.currentlap*1000000 + g.nextCheckPointId*100000 - int(toCheckPointV.length())
+	return g
 }
 
 func determineLeader(players [2]gamer) int {
@@ -476,65 +1772,416 @@ func opponentLeads(players [2]gamer, opponents [2]gamer) bool {
 	return players[playerLeadId].advancement < opponents[opponentLeadId].advancement
 }
 
-func readOpponents(state gameState, track map[int]*checkpoint) [2]gamer {
+func readOpponents(in io.Reader, track map[int]*checkpoint) ([2]gamer, error) {
 	var opponents [2]gamer
 	for i := 0; i < 2; i++ {
 		var x2, y2, vx2, vy2, angle2, nextCheckPointId2 int
-		fmt.Scan(&x2, &y2, &vx2, &vy2, &angle2, &nextCheckPointId2)
-		opponents[i] = gamer{x2, y2, vx2, vy2, angle2, nextCheckPointId2, state.opponents[i].advancement, state.opponents[i].currentlap}
-		if state.opponents[i].nextCheckPointId != nextCheckPointId2 {
+		if _, err := fmt.Fscan(in, &x2, &y2, &vx2, &vy2, &angle2, &nextCheckPointId2); err != nil {
+			return opponents, err
+		}
+		if track[nextCheckPointId2] == nil {
+			return opponents, fmt.Errorf("opponent %d goes for checkpoint %d, which isn't on the track", i, nextCheckPointId2)
+		}
+		opponents[i] = gamer{x: x2, y: y2, vx: vx2, vy: vy2, angle: angle2, nextCheckPointId: nextCheckPointId2}
+	}
+	return opponents, nil
+}
+
+// observeTurn updates state with the pods as sent this turn, ours then the
+// opponent's, and infers which opponents shielded when it knows how ours
+// were commanded.
+func observeTurn(state *gameState, pods [4]gamer, track map[int]*checkpoint) {
+	prev := *state
+	for i := 0; i < 2; i++ {
+		p, o := pods[i], pods[2+i]
+		if prev.players[i].nextCheckPointId != p.nextCheckPointId {
 			// new checkpoint
-			if nextCheckPointId2 == 0 {
-				opponents[i].currentlap = opponents[i].currentlap + 1
+			fmt.Fprintf(os.Stderr, "NEW nextCheckPointId %d for player %d\n", p.nextCheckPointId, i)
+		}
+		state.players[i] = observe(prev.players[i], p.x, p.y, p.vx, p.vy, p.angle, p.nextCheckPointId, track)
+		state.opponents[i] = observe(prev.opponents[i], o.x, o.y, o.vx, o.vy, o.angle, o.nextCheckPointId, track)
+	}
+	if prev.commandsKnown {
+		inferShields(prev, state, track)
+	}
+}
+
+// shieldPush is the least push, in speed, a collision must give our pod for
+// the opponent's share of it to tell whether it was shielded: unshielded it
+// takes as much, give or take its thrust, shielded a tenth.
+const shieldPush = 300
+
+// inferShields works out from the last turn, prev, which opponents state
+// has shielded: those that took a tenth of the push of a collision with
+// one of our pods. Our pods' commands of the last turn, which must be
+// known, tell how they would have moved without it.
+func inferShields(prev gameState, state *gameState, track map[int]*checkpoint) {
+	for i := range state.players {
+		p := newSimPod(prev.players[i])
+		p.apply(prev.commands[i])
+		p.move(1, track)
+		p.endTurn()
+		now := state.players[i]
+		pushX, pushY := (float64(now.vx)-p.vx)/frictionFactor*p.mass, (float64(now.vy)-p.vy)/frictionFactor*p.mass
+		if math.Hypot(pushX, pushY) < shieldPush {
+			continue
+		}
+		closest, distance := -1, 2*podRadius+math.Hypot(pushX, pushY)
+		for j, o := range state.opponents {
+			if d := math.Hypot(float64(o.x-now.x), float64(o.y-now.y)); d < distance {
+				closest, distance = j, d
 			}
 		}
-		toCheckPointV := NewSmartVectorCartesian(float64(track[nextCheckPointId2].center.x-x2), float64(track[nextCheckPointId2].center.y-y2))
-		opponents[i].advancement = opponents[i].currentlap*1000000 + opponents[i].nextCheckPointId*100000 - int(toCheckPointV.length)
+		if closest < 0 {
+			continue
+		}
+		before, after := prev.opponents[closest], state.opponents[closest]
+		// what pushed the opponent, thrust and collision, before friction
+		ax, ay := float64(after.vx)/frictionFactor-float64(before.vx), float64(after.vy)/frictionFactor-float64(before.vy)
+		sin, cos := math.Sincos(AngleDegrees(float64(after.angle)).Radians())
+		thrust := math.Max(0, math.Min(100, (ax+pushX)*cos+(ay+pushY)*sin))
+		unshielded := math.Hypot(ax+pushX-thrust*cos, ay+pushY-thrust*sin)
+		if shielded := math.Hypot(ax+pushX/shieldMass, ay+pushY/shieldMass); shielded < unshielded {
+			fmt.Fprintf(os.Stderr, "opponent %d shielded against player %d\n", closest, i)
+			state.opponents[closest].shield = shieldTurns
+		}
 	}
-	return opponents
 }
 
-func readTrack() map[int]*checkpoint {
+func readTrack(in io.Reader) (map[int]*checkpoint, error) {
 	var checkpointCount int
-	fmt.Scan(&checkpointCount)
+	if _, err := fmt.Fscan(in, &checkpointCount); err != nil {
+		return nil, err
+	}
+	if checkpointCount < 1 {
+		return nil, fmt.Errorf("a track of %d checkpoints", checkpointCount)
+	}
 	var track map[int]*checkpoint = make(map[int]*checkpoint)
 	for id := 0; id < checkpointCount; id++ {
 		var checkpointX, checkpointY int
-		fmt.Scan(&checkpointX, &checkpointY)
+		if _, err := fmt.Fscan(in, &checkpointX, &checkpointY); err != nil {
+			return nil, err
+		}
 		track[id] = &checkpoint{
 			center:               point{checkpointX, checkpointY},
 			longDistanceAimpoint: point{checkpointX, checkpointY},
 		}
 	}
 	calculateAimpoints(track)
-	return track
+	return track, nil
+}
+
+// Leagues the bot can be submitted to. Up to silver they send the single
+// pod protocol, gold sends the whole race.
+const (
+	leagueWood   = "wood"
+	leagueBronze = "bronze"
+	leagueSilver = "silver"
+	leagueGold   = "gold"
+)
+
+// league is the league the bot is submitted to. Locally the CSB_LEAGUE
+// environment variable overrides it.
+var league = leagueGold
+
+// leagueRules are the rules of a league: whether pods collide, what they
+// may do, how many each side races, and whether the bots are sent the whole
+// race or the single pod protocol.
+type leagueRules struct {
+	collisions    bool
+	boost, shield bool
+	pods          int
+	fullInput     bool
+}
+
+// rulesOf has the rules of each league. The wood leagues are taken at their
+// strictest: no collisions, thrust only.
+var rulesOf = map[string]leagueRules{
+	leagueWood:   {pods: 1},
+	leagueBronze: {collisions: true, boost: true, shield: true, pods: 1},
+	leagueSilver: {collisions: true, boost: true, shield: true, pods: 1},
+	leagueGold:   {collisions: true, boost: true, shield: true, pods: 2, fullInput: true},
+}
+
+// allowed is the command as the rules let us give it: full thrust instead
+// of a BOOST, and the thrust we had instead of a SHIELD, where the league
+// has none.
+func (c command) allowed(rules leagueRules) command {
+	if c.boost && !rules.boost {
+		c.boost, c.thrust = false, 100
+	}
+	if c.shield && !rules.shield {
+		c.shield = false
+	}
+	return c
+}
+
+// singlePodLaps are the laps of the races in the single pod protocol, which
+// doesn't tell.
+const singlePodLaps = 3
+
+// parkedPod is where the pods the single pod protocol doesn't have are
+// parked, far enough not to count for anything.
+var parkedPod = point{-100000, -100000}
+
+// trackLearner learns the track of the single pod protocol from the
+// checkpoints we are sent to, numbered in the order they come. Once we are
+// sent back to checkpoint 0 we went round, and the track is known.
+type trackLearner struct {
+	seen  []point
+	known bool
+	track map[int]*checkpoint
 }
 
+// learn records that we are sent to target, and returns the track as far as
+// it is known, with the id of target on it. Until the track is known, its
+// last checkpoint is the one we go for and aims at nothing further.
+func (l *trackLearner) learn(target point) (map[int]*checkpoint, int) {
+	for id, center := range l.seen {
+		if center != target {
+			continue
+		}
+		if !l.known && id == 0 && len(l.seen) > 1 {
+			l.known = true
+			calculateAimpoints(l.track)
+		}
+		return l.track, id
+	}
+	if l.known {
+		// not on the track we learnt: start learning again
+		*l = trackLearner{}
+	}
+	l.seen = append(l.seen, target)
+	l.track = make(map[int]*checkpoint)
+	for id, center := range l.seen {
+		l.track[id] = &checkpoint{center: center}
+	}
+	calculateAimpoints(l.track)
+	last := l.track[len(l.seen)-1]
+	last.longDistanceAimpoint, last.nextAimpoint = last.center, last.center
+	return l.track, len(l.seen) - 1
+}
+
+// localCommands are the tools that come with the bot when it runs locally,
+// "go run ./gold <command>". Their files are not part of the submission.
+var localCommands = map[string]func(args []string){}
+
 func main() {
-	var laps int
-	fmt.Scan(&laps)
-	track := readTrack()
+	if len(os.Args) > 1 {
+		if run, ok := localCommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
 
-	state := initGameState(track, laps)
+	if s := os.Getenv("CSB_STRATEGY"); s != "" {
+		strategy = s
+	}
+	if l := os.Getenv("CSB_LEAGUE"); l != "" {
+		league = l
+	}
+	if os.Getenv("CSB_ECHO") != "" {
+		echoInput = true
+	}
+	if err := runGame(os.Stdin, os.Stdout, turnBudget); err != nil {
+		fmt.Fprintln(os.Stderr, "game over:", err)
+	}
+}
 
+// runGame plays the game read from in, writing our commands to out, until
+// the input ends. Each turn gets budget to think. With echoInput the input
+// is echoed to stderr, for the replays to have it.
+func runGame(in io.Reader, out io.Writer, budget time.Duration) error {
+	if echoInput {
+		in = io.TeeReader(in, &echoWriter{w: os.Stderr})
+	}
+	return runTeam(playTurn, in, out, budget)
+}
+
+// runTeam plays t on the game read from in like runGame the bot.
+func runTeam(t team, in io.Reader, out io.Writer, budget time.Duration) error {
+	read, state, pods, err := newTurnReader(in)
+	if err != nil {
+		return err
+	}
 	for {
-		players := readPlayers(state, track)
-		state.players = players
+		track, err := read(&state)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(budget)
 
-		leaderId := determineLeader(players)
-		opponents := readOpponents(state, track)
-		state.opponents = opponents
+		cmds := takeTurn(t, &state, track, deadline, rulesOf[league])
+		for _, cmd := range cmds[:pods] {
+			fmt.Fprintln(out, cmd.allowed(rulesOf[league]))
+		}
+	}
+}
 
-		for playerId := 0; playerId < 2; playerId++ {
-			isLeader := playerId == leaderId
+// takeTurn has t decide our commands on the turn read into state, and
+// records what the game takes of them under rules.
+func takeTurn(t team, state *gameState, track map[int]*checkpoint, deadline time.Time, rules leagueRules) [2]command {
+	cmds := t(state, track, deadline)
+	state.first = false
+	var taken [2]command
+	for i, cmd := range cmds {
+		taken[i] = cmd.allowed(rules)
+	}
+	state.played(taken)
+	return cmds
+}
 
-			boostUsed := movePlayer(playerId, isLeader, state, track)
+// turnReader reads the next turn of a game into state, and returns the
+// track as the bot knows it then. It returns io.EOF once the game is over.
+type turnReader func(state *gameState) (map[int]*checkpoint, error)
 
-			if boostUsed && !state.first {
-				state.usedboost = true
-			}
+// newTurnReader starts reading the game from in, and returns the reader of
+// its turns, the state before the first one and how many pods we command.
+// The leagues up to silver start the game with a turn of the single pod
+// protocol, six numbers, the gold league with the number of laps alone.
+func newTurnReader(in io.Reader) (turnReader, gameState, int, error) {
+	input := bufio.NewReader(in)
+	firstLine, err := input.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, gameState{}, 0, err
+	}
+	input = bufio.NewReader(io.MultiReader(strings.NewReader(firstLine), input))
+	if len(strings.Fields(firstLine)) == 6 {
+		return singlePodReader(input), initGameState(nil, singlePodLaps), 1, nil
+	}
+	var laps int
+	if _, err := fmt.Fscan(input, &laps); err != nil {
+		return nil, gameState{}, 0, err
+	}
+	track, err := readTrack(input)
+	if err != nil {
+		return nil, gameState{}, 0, err
+	}
+	read := func(state *gameState) (map[int]*checkpoint, error) {
+		players, err := readPlayers(input, track)
+		if err != nil {
+			return nil, err
+		}
+		opponents, err := readOpponents(input, track)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		observeTurn(state, [4]gamer{players[0], players[1], opponents[0], opponents[1]}, track)
+		return track, nil
+	}
+	return read, initGameState(track, laps), 2, nil
+}
+
+// echoInput echoes our input to stderr, the only way for the replay of a
+// game to have it and be imported. It is off unless a submission is meant
+// to be replayed, the echo taking much of what stderr CodinGame keeps each
+// turn. Locally the CSB_ECHO environment variable turns it on.
+var echoInput = false
+
+// echoPrefix starts the lines of input we echo to stderr, to tell them from
+// the debug output when importing a replay.
+const echoPrefix = "> "
+
+// echoWriter writes to w what it is given, each line behind echoPrefix.
+// Lines are only written once whole, so the debug output written meanwhile
+// doesn't break them. Failing to write doesn't fail the reads it is echoing.
+type echoWriter struct {
+	w    io.Writer
+	line []byte
+}
+
+func (e *echoWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if len(e.line) == 0 {
+			e.line = append(e.line, echoPrefix...)
+		}
+		e.line = append(e.line, c)
+		if c == '\n' {
+			e.w.Write(e.line)
+			e.line = e.line[:0]
+		}
+	}
+	return len(p), nil
+}
+
+// singlePodReader reads the turns of the single pod protocol of the leagues
+// up to silver.
+func singlePodReader(input io.Reader) turnReader {
+	var adapter singlePodAdapter
+	return func(state *gameState) (map[int]*checkpoint, error) {
+		var in singlePodTurn
+		if _, err := fmt.Fscan(input, &in.x, &in.y, &in.nextCheckpointX, &in.nextCheckpointY, &in.nextCheckpointDist, &in.nextCheckpointAngle); err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fscan(input, &in.opponentX, &in.opponentY); err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
 		}
+		return adapter.read(state, in), nil
+	}
+}
+
+// singlePodTurn is a turn's input in the single pod protocol: our pod's
+// position, its next checkpoint and the distance and angle to it, and the
+// opponent's position.
+type singlePodTurn struct {
+	x, y                                    int
+	nextCheckpointX, nextCheckpointY        int
+	nextCheckpointDist, nextCheckpointAngle int
+	opponentX, opponentY                    int
+}
+
+// singlePodAdapter makes the state the gold league would have sent out of
+// the single pod protocol: the track as far as we have seen it, speeds
+// estimated from the moves, and the missing pods parked out of the way.
+type singlePodAdapter struct {
+	learner trackLearner
+}
 
-		state.first = false
+// estimate is the speed of the pod at (x, y) that was prev last turn, its
+// last move slowed down by the friction, and the angle it moved in, or
+// prev's if it didn't move: where it faces as far as its moves tell.
+func estimate(prev gamer, x, y int) (vx, vy, angle int) {
+	vx, vy, angle = int(float64(x-prev.x)*frictionFactor), int(float64(y-prev.y)*frictionFactor), prev.angle
+	if x != prev.x || y != prev.y {
+		angle = int(math.Round(NewSmartVectorCartesian(float64(x-prev.x), float64(y-prev.y)).angleDegrees())+360) % 360
 	}
+	return vx, vy, angle
+}
+
+// read updates state with this turn's input and returns the track known so
+// far.
+func (a *singlePodAdapter) read(state *gameState, in singlePodTurn) map[int]*checkpoint {
+	track, nextCheckPointId := a.learner.learn(point{in.nextCheckpointX, in.nextCheckpointY})
+	state.numcheckpoints = len(track)
+	prev := state.players[0]
+	if state.first {
+		prev.x, prev.y = in.x, in.y
+	}
+	// Our pod faces nextCheckpointAngle off the checkpoint.
+	vx, vy, _ := estimate(prev, in.x, in.y)
+	toCheckpointV := NewSmartVectorCartesian(float64(in.nextCheckpointX-in.x), float64(in.nextCheckpointY-in.y))
+	angle := (int(math.Round(toCheckpointV.angleDegrees())) - in.nextCheckpointAngle) % 360
+	if angle < 0 {
+		angle += 360
+	}
+	state.players[0] = observe(prev, in.x, in.y, vx, vy, angle, nextCheckPointId, track)
+	state.players[1] = observe(state.players[1], parkedPod.x, parkedPod.y, 0, 0, 0, nextCheckPointId, track)
+
+	prev = state.opponents[0]
+	if state.first {
+		prev.x, prev.y = in.opponentX, in.opponentY
+	}
+	// We don't know where the opponent goes: it is taken to race for our
+	// checkpoint.
+	vx, vy, angle = estimate(prev, in.opponentX, in.opponentY)
+	state.opponents[0] = observe(prev, in.opponentX, in.opponentY, vx, vy, angle, nextCheckPointId, track)
+	state.opponents[1] = observe(state.opponents[1], parkedPod.x, parkedPod.y, 0, 0, 0, nextCheckPointId, track)
+	return track
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAnnealWarmStart(t *testing.T) {
+	defer func(r *rand.Rand) { searchRand = r }(searchRand)
+	searchRand = rand.New(rand.NewSource(1))
+
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	state := initGameState(track, 3)
+	state.first = false
+	pods := benchmarkPods()
+	for i := 0; i < 2; i++ {
+		state.players[i] = podGamer(state.players[i], pods[i], track)
+		state.opponents[i] = podGamer(state.opponents[i], pods[2+i], track)
+	}
+	root := newRace(state, 0)
+
+	// a plan better than the default one, that shifting leaves as it is
+	// past its end
+	best := defaultPlan(root, track)
+	bestScore := evaluatePlan(root, &best, track)
+	for k := 0; k < 1000; k++ {
+		candidate := best
+		annealing.mutate(&candidate)
+		candidate[annealHorizon-1] = candidate[annealHorizon-2]
+		for i := range candidate[annealHorizon-1] {
+			candidate[annealHorizon-1][i].shield, candidate[annealHorizon-1][i].boost = false, false
+		}
+		if score := evaluatePlan(root, &candidate, track); score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if defaultPlan := defaultPlan(root, track); best == defaultPlan {
+		t.Fatal("no plan better than the default one")
+	}
+
+	// last turn's plan, which is best once the turn played is shifted out
+	var last annealPlan
+	copy(last[1:], best[:annealHorizon-1])
+	if last.shifted() != best {
+		t.Fatalf("shifting the last plan gives %+v, want %+v", last.shifted(), best)
+	}
+	state.plan = &last
+
+	cmds := annealMove(&state, track, 0, time.Now())
+	if *state.plan != best {
+		t.Errorf("with no time to anneal, kept %+v, want the warm start %+v", *state.plan, best)
+	}
+	for i := range cmds {
+		if want := root.legal(best[0][i]).command(root.pods[i]); cmds[i] != want {
+			t.Errorf("pod %d played %v, want the warm start's %v", i, cmds[i], want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+// Bots the referee runs: the headless games race bots in process, with no
+// process to start nor text to parse, and a bot of any language over the
+// game's protocol in a process of its own, for the final checks before a
+// submission:
+//
+//	go build -o /tmp/bronze ./bronze
+//	go run ./gold tournament -bots heuristic,searcher,bronze,bronze:/tmp/bronze
+//
+// races our heuristics, the zoo's searcher and the bronze bot, in process
+// and in a process of its own, against each other. Our bots in process play
+// the game's league, over the single pod protocol's adapter in the leagues
+// up to silver like the gold bot does there. The bronze bot speaks the
+// single pod protocol in every league, and commands our first pod alone.
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeingame-csb/internal/bronzebot"
+)
+
+func init() {
+	localCommands["tournament"] = tournament
+}
+
+// bot is a bot the referee runs. Init starts a game of laps on the track,
+// and Turn is sent the four pods each turn, ours then the opponent's, and
+// answers the commands of our two.
+type bot interface {
+	Init(laps int, track map[int]*checkpoint)
+	Turn(pods [4]podState) [2]command
+}
+
+// podState is a pod as the gold league's protocol sends it.
+type podState struct {
+	x, y, vx, vy, angle, nextCheckPointId int
+}
+
+func (p podState) gamer() gamer {
+	return gamer{x: p.x, y: p.y, vx: p.vx, vy: p.vy, angle: p.angle, nextCheckPointId: p.nextCheckPointId}
+}
+
+// playBots races bots[0] against bots[1] until a side wins or time is up.
+// In the leagues up to silver the pods not racing are parked, and the bots
+// in process are still told where everything is.
+func (r *headlessGame) playBots(bots [2]bot) gameResult {
+	for _, b := range bots {
+		b.Init(r.laps, r.track)
+	}
+	return r.run(func(side int) [2]command {
+		return bots[side].Turn(r.podStates(side))
+	})
+}
+
+// podStates are the four pods as side is sent them.
+func (r *headlessGame) podStates(side int) [4]podState {
+	n := r.rules.pods
+	var pods [4]podState
+	for i, first := range [2]int{n * side, n * (1 - side)} {
+		for j := 0; j < 2; j++ {
+			p := r.pods[first]
+			if j < n {
+				p = r.pods[first+j]
+			} else {
+				p.x, p.y, p.vx, p.vy = float64(parkedPod.x), float64(parkedPod.y), 0, 0
+			}
+			pods[2*i+j] = podState{int(p.x), int(p.y), int(p.vx), int(p.vy), int(p.angle.Degrees()), p.nextCheckPointId}
+		}
+	}
+	return pods
+}
+
+// teamBot runs t in process in a game by rules, the pods making the state
+// the gold bot reads from its input, or the single pod protocol's adapter
+// makes out of it.
+type teamBot struct {
+	t       team
+	budget  time.Duration
+	rules   leagueRules
+	state   gameState
+	track   map[int]*checkpoint // the track of the game
+	seen    map[int]*checkpoint // the track as the bot knows it
+	adapter singlePodAdapter
+}
+
+func newTeamBot(t team, budget time.Duration, rules leagueRules) *teamBot {
+	return &teamBot{t: t, budget: budget, rules: rules}
+}
+
+func (b *teamBot) Init(laps int, track map[int]*checkpoint) {
+	b.track, b.seen, b.adapter = track, track, singlePodAdapter{}
+	b.state = initGameState(track, laps)
+	if !b.rules.fullInput {
+		b.state = initGameState(nil, singlePodLaps)
+	}
+}
+
+func (b *teamBot) Turn(pods [4]podState) [2]command {
+	if b.rules.fullInput {
+		observeTurn(&b.state, [4]gamer{pods[0].gamer(), pods[1].gamer(), pods[2].gamer(), pods[3].gamer()}, b.track)
+	} else {
+		b.seen = b.adapter.read(&b.state, singlePodInput(pods[0].gamer(), pods[2].gamer(), b.track))
+	}
+	return takeTurn(b.t, &b.state, b.seen, time.Now().Add(b.budget), b.rules)
+}
+
+// bronzeBot runs the bronze bot in process, as its program plays: over the
+// single pod protocol, commanding our first pod alone.
+type bronzeBot struct {
+	bot   *bronzebot.Bot
+	track map[int]*checkpoint
+}
+
+func newBronzeBot() *bronzeBot {
+	return &bronzeBot{}
+}
+
+func (b *bronzeBot) Init(laps int, track map[int]*checkpoint) {
+	b.bot, b.track = bronzebot.New(), track
+}
+
+func (b *bronzeBot) Turn(pods [4]podState) [2]command {
+	in := singlePodInput(pods[0].gamer(), pods[2].gamer(), b.track)
+	cmd, err := parseCommand(b.bot.Turn(bronzebot.Input{
+		X: in.x, Y: in.y,
+		NextCheckpointX: in.nextCheckpointX, NextCheckpointY: in.nextCheckpointY,
+		NextCheckpointDist: in.nextCheckpointDist, NextCheckpointAngle: in.nextCheckpointAngle,
+		OpponentX: in.opponentX, OpponentY: in.opponentY,
+	}))
+	if err != nil {
+		cmd = command{x: pods[0].x, y: pods[0].y}
+	}
+	return [2]command{cmd, {x: pods[1].x, y: pods[1].y}}
+}
+
+// processBot runs a program over the game's protocol, a process for each
+// game as on CodinGame. singlePod programs speak the single pod protocol
+// of the leagues up to silver, and command our first pod alone. A program
+// that fails, or takes longer than timeout to answer a turn, is stopped and
+// its pods stay put for the rest of the game.
+type processBot struct {
+	name      string
+	args      []string
+	singlePod bool
+	timeout   time.Duration
+
+	cmd   *exec.Cmd
+	in    *bufio.Writer
+	lines chan string
+	track map[int]*checkpoint
+	err   error
+}
+
+func newProcessBot(singlePod bool, timeout time.Duration, name string, args ...string) *processBot {
+	return &processBot{name: name, args: args, singlePod: singlePod, timeout: timeout}
+}
+
+func (b *processBot) Init(laps int, track map[int]*checkpoint) {
+	b.Close()
+	b.track, b.err = track, nil
+	b.cmd = exec.Command(b.name, b.args...)
+	in, err := b.cmd.StdinPipe()
+	if err != nil {
+		b.err = err
+		return
+	}
+	out, err := b.cmd.StdoutPipe()
+	if err != nil {
+		b.err = err
+		return
+	}
+	if err := b.cmd.Start(); err != nil {
+		b.err = err
+		return
+	}
+	b.in = bufio.NewWriter(in)
+	b.lines = make(chan string)
+	go func(lines chan<- string) {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}(b.lines)
+	if !b.singlePod {
+		writeGoldHeader(b.in, track, laps)
+	}
+}
+
+func (b *processBot) Turn(pods [4]podState) [2]command {
+	if b.err != nil {
+		return b.stay(pods)
+	}
+	var state gameState
+	for i := 0; i < 2; i++ {
+		state.players[i], state.opponents[i] = pods[i].gamer(), pods[2+i].gamer()
+	}
+	n := 2
+	if b.singlePod {
+		writeBronzeTurn(b.in, state, b.track)
+		n = 1
+	} else {
+		writeGoldTurn(b.in, state)
+	}
+	if b.err = b.in.Flush(); b.err != nil {
+		return b.stay(pods)
+	}
+	var cmds [2]command
+	timeout := time.After(b.timeout)
+	for i := 0; i < n; i++ {
+		select {
+		case line, ok := <-b.lines:
+			if !ok {
+				b.err = errors.New("the bot stopped")
+				return b.stay(pods)
+			}
+			if cmds[i], b.err = parseCommand(line); b.err != nil {
+				return b.stay(pods)
+			}
+		case <-timeout:
+			b.err = fmt.Errorf("no answer in %v", b.timeout)
+			return b.stay(pods)
+		}
+	}
+	if b.singlePod {
+		cmds[1] = b.stay(pods)[1]
+	}
+	return cmds
+}
+
+// stay keeps our pods where they are, once the program failed.
+func (b *processBot) stay(pods [4]podState) [2]command {
+	return [2]command{{x: pods[0].x, y: pods[0].y}, {x: pods[1].x, y: pods[1].y}}
+}
+
+// Close stops the program of the game under way, and returns how it failed
+// if it did.
+func (b *processBot) Close() error {
+	if b.cmd == nil || b.cmd.Process == nil {
+		return b.err
+	}
+	b.cmd.Process.Kill()
+	for range b.lines {
+	}
+	b.cmd.Wait()
+	b.cmd, b.lines = nil, nil
+	return b.err
+}
+
+// parseCommand reads a command as the referee does: "X Y THRUST", the
+// thrust being BOOST or SHIELD or 0 to 100.
+func parseCommand(line string) (command, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return command{}, fmt.Errorf("%q isn't X Y THRUST", line)
+	}
+	var cmd command
+	var err error
+	if cmd.x, err = strconv.Atoi(fields[0]); err != nil {
+		return cmd, fmt.Errorf("%q: %v", line, err)
+	}
+	if cmd.y, err = strconv.Atoi(fields[1]); err != nil {
+		return cmd, fmt.Errorf("%q: %v", line, err)
+	}
+	if !setThrust(&cmd, fields[2]) {
+		return cmd, fmt.Errorf("%q: thrust %q", line, fields[2])
+	}
+	return cmd, nil
+}
+
+// setThrust sets the thrust of cmd to BOOST, SHIELD or 0 to 100, and
+// returns whether it was one of them.
+func setThrust(cmd *command, thrust string) bool {
+	switch thrust {
+	case "BOOST":
+		cmd.boost = true
+	case "SHIELD":
+		cmd.shield = true
+	default:
+		n, err := strconv.Atoi(thrust)
+		if err != nil || n < 0 || n > 100 {
+			return false
+		}
+		cmd.thrust = n
+	}
+	return true
+}
+
+// newBotOf makes the bots of spec for games by rules, a new one for each
+// game: a strategy of ours, a bot of the zoo or the bronze bot in process,
+// or "gold:PROGRAM" and "bronze:PROGRAM" for a program speaking the gold or
+// the single pod protocol.
+func newBotOf(spec string, rules leagueRules, budget, timeout time.Duration) (func() bot, error) {
+	if kind := strings.SplitN(spec, ":", 2); len(kind) == 2 && (kind[0] == leagueGold || kind[0] == leagueBronze) {
+		fields := strings.Fields(kind[1])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%q has no program", spec)
+		}
+		return func() bot {
+			return newProcessBot(kind[0] == leagueBronze, timeout, fields[0], fields[1:]...)
+		}, nil
+	}
+	switch spec {
+	case strategyHeuristic, strategyMCTS, strategyAnnealing, strategyNeural:
+		return func() bot { return newTeamBot(strategyTeam(spec, policyNet), budget, rules) }, nil
+	case leagueBronze:
+		return func() bot { return newBronzeBot() }, nil
+	}
+	if newTeam, ok := zoo[spec]; ok {
+		return func() bot { return newTeamBot(newTeam(), budget, rules) }, nil
+	}
+	return nil, fmt.Errorf("no bot %q: a strategy, a bot of the zoo, bronze, gold:PROGRAM or bronze:PROGRAM", spec)
+}
+
+// tournamentGame races a new bot of each spec against each other on the
+// track, a on side 0, and returns the result and how the bots failed, if
+// they did.
+func tournamentGame(a, b func() bot, track map[int]*checkpoint, rules leagueRules) (gameResult, error) {
+	bots := [2]bot{a(), b()}
+	result := newHeadlessGame(track, gameLaps, rules).playBots(bots)
+	var err error
+	for _, b := range bots {
+		if c, ok := b.(io.Closer); ok {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return result, err
+}
+
+func tournament(args []string) {
+	flags := flag.NewFlagSet("tournament", flag.ExitOnError)
+	specs := flags.String("bots", "heuristic,"+strings.Join(zooNames(), ","), "bots racing each other, comma separated: strategies, bots of the zoo, bronze, gold:PROGRAM or bronze:PROGRAM")
+	games := flags.Int("games", 10, "games each pair of bots plays")
+	seed := flags.Int64("seed", 1, "seed of the tracks")
+	leagueName := flags.String("league", leagueGold, "league whose rules the games are played by")
+	budget := flags.Duration("budget", 0, "time the bots in process get per turn")
+	timeout := flags.Duration("timeout", time.Second, "time the programs get to answer a turn")
+	flags.Parse(args)
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	rules, ok := rulesOf[*leagueName]
+	if !ok {
+		fmt.Println("no league", *leagueName)
+		os.Exit(1)
+	}
+	names := strings.Split(*specs, ",")
+	bots := make([]func() bot, len(names))
+	for i, name := range names {
+		b, err := newBotOf(name, rules, *budget, *timeout)
+		if err != nil {
+			fmt.Println("cannot make the bot:", err)
+			os.Exit(1)
+		}
+		bots[i] = b
+	}
+
+	wins := make([]int, len(names))
+	fmt.Printf("%-20s %-20s %8s\n", "bot", "against", "wins")
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			rnd := rand.New(rand.NewSource(*seed))
+			won, lost := 0, 0
+			for game := 0; game < *games; game++ {
+				track := randomTrack(rnd)
+				side := game % 2
+				a, b := bots[i], bots[j]
+				if side == 1 {
+					a, b = b, a
+				}
+				result, err := tournamentGame(a, b, track, rules)
+				if err != nil {
+					fmt.Printf("%s against %s, game %d: %v\n", names[i], names[j], game+1, err)
+				}
+				switch result.winner {
+				case side:
+					won++
+				case 1 - side:
+					lost++
+				}
+			}
+			wins[i] += won
+			wins[j] += lost
+			fmt.Printf("%-20s %-20s %4d/%-3d\n", names[i], names[j], won, *games)
+		}
+	}
+	fmt.Println()
+	for i, name := range names {
+		fmt.Printf("%-20s %4d wins\n", name, wins[i])
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"codeingame-csb/internal/bronzebot"
+)
+
+func TestTeamBotsPlayLikeTeams(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(2)))
+	for _, l := range []string{leagueGold, leagueBronze} {
+		rules := rulesOf[l]
+		teams := playLeagueGame(track, gameLaps, [2]team{strategyTeam(strategyHeuristic, nil), zoo["searcher"]()}, rules, 0, nil)
+		bots := [2]bot{newTeamBot(strategyTeam(strategyHeuristic, nil), 0, rules), newTeamBot(zoo["searcher"](), 0, rules)}
+		if played := newHeadlessGame(track, gameLaps, rules).playBots(bots); played != teams {
+			t.Errorf("%s: bots in process played %+v, the teams %+v", l, played, teams)
+		}
+	}
+}
+
+// transcriptBot writes what its bot is sent over the single pod protocol,
+// and the commands of our first pod it answers.
+type transcriptBot struct {
+	bot
+	track   map[int]*checkpoint
+	in, out bytes.Buffer
+}
+
+func (b *transcriptBot) Init(laps int, track map[int]*checkpoint) {
+	b.track = track
+	b.bot.Init(laps, track)
+}
+
+func (b *transcriptBot) Turn(pods [4]podState) [2]command {
+	var state gameState
+	state.players[0], state.opponents[0] = pods[0].gamer(), pods[2].gamer()
+	writeBronzeTurn(&b.in, state, b.track)
+	cmds := b.bot.Turn(pods)
+	fmt.Fprintln(&b.out, cmds[0])
+	return cmds
+}
+
+func TestBronzeBot(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(2)))
+	bronze := &transcriptBot{bot: newBronzeBot()}
+	result := newHeadlessGame(track, gameLaps, rulesOf[leagueBronze]).playBots([2]bot{bronze, newTeamBot(zoo["follower"](), 0, rulesOf[leagueBronze])})
+	if result.turns >= gameMaxTurns {
+		t.Errorf("game still running after %d turns: %+v", result.turns, result)
+	}
+	// In process, the bronze bot plays as its program does over the same input.
+	var out bytes.Buffer
+	if err := bronzebot.Run(&bronze.in, &out); err != nil {
+		t.Fatal(err)
+	}
+	var program bytes.Buffer
+	for _, line := range splitLines(out.String()) {
+		cmd, err := parseCommand(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintln(&program, cmd)
+	}
+	if program.String() != bronze.out.String() {
+		t.Errorf("the bronze bot played differently in process and over its input")
+	}
+}
+
+func TestProcessBot(t *testing.T) {
+	for _, l := range []string{leagueGold, leagueBronze} {
+		t.Run(l, func(t *testing.T) {
+			os.Setenv("CSB_TEST_BOT_LEAGUE", l)
+			defer os.Unsetenv("CSB_TEST_BOT_LEAGUE")
+			track := randomTrack(rand.New(rand.NewSource(3)))
+			rules := rulesOf[l]
+			program := func() bot { return newProcessBot(l != leagueGold, 10*time.Second, os.Args[0]) }
+			heuristics := func() bot { return newTeamBot(strategyTeam(strategyHeuristic, nil), 0, rules) }
+			result, err := tournamentGame(program, heuristics, track, rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The program is our bot, which plays the same in process.
+			if inProcess, _ := tournamentGame(heuristics, heuristics, track, rules); result != inProcess {
+				t.Errorf("the program played %+v, in process %+v", result, inProcess)
+			}
+			t.Logf("%+v", result)
+			if result.turns >= gameMaxTurns {
+				t.Errorf("game still running after %d turns: %+v", result.turns, result)
+			}
+		})
+	}
+}
+
+func TestProcessBotFails(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(3)))
+	for _, program := range []func() bot{
+		func() bot { return newProcessBot(false, time.Second, "/nonexistent/bot") },
+		func() bot { return newProcessBot(false, 100*time.Millisecond, "sleep", "10") },
+		func() bot { return newProcessBot(false, time.Second, "echo", "nonsense") },
+	} {
+		result, err := tournamentGame(program, func() bot { return newTeamBot(zoo["follower"](), 0, rulesOf[leagueGold]) }, track, rulesOf[leagueGold])
+		if err == nil || result.winner != 1 {
+			t.Errorf("a failing program: %+v, error %v", result, err)
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	for line, want := range map[string]command{
+		"1 2 30":       {x: 1, y: 2, thrust: 30},
+		"-5 7 BOOST":   {x: -5, y: 7, boost: true},
+		"0 0 SHIELD":   {shield: true},
+		" 3  4 100 \r": {x: 3, y: 4, thrust: 100},
+	} {
+		if got, err := parseCommand(line); err != nil || got != want {
+			t.Errorf("%q is %v, %v; want %v", line, got, err, want)
+		}
+	}
+	for _, line := range []string{"", "1 2", "1 2 101", "1 2 -1", "x 2 3", "1 2 3 4", "1 2 boost"} {
+		if _, err := parseCommand(line); err == nil {
+			t.Errorf("%q parsed", line)
+		}
+	}
+}
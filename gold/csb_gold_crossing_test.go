@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCrossesBetweenPositions(t *testing.T) {
+	// going from (0, 0) to (2000, 0) in a turn, neither end is within the
+	// checkpoint's radius of (1000, 500), the path in between is
+	p := simPod{vx: 2000}
+	if !p.crosses(point{1000, 500}, 1) {
+		t.Error("passing 500 from the checkpoint doesn't cross it")
+	}
+	if p.crosses(point{1000, 700}, 1) {
+		t.Error("passing 700 from the checkpoint crosses it")
+	}
+	if p.crosses(point{1000, 500}, 0.25) {
+		t.Error("crosses the checkpoint before getting near it")
+	}
+	if !(&simPod{x: 1000, y: 500}).crosses(point{1000, 500}, 1) {
+		t.Error("a pod sitting on the checkpoint doesn't cross it")
+	}
+}
+
+func TestCheckpointHitTurn(t *testing.T) {
+	// drifting at 1500, the pod is at 1500 after turn 1 and 2775 after
+	// turn 2, both over 600 from the checkpoint, which it crosses in between
+	coast := holdCommand(command{x: 10000, y: 0, thrust: 0})
+	if turn := checkpointHitTurn(simPod{vx: 1500, mass: 1}, coast[:], point{2150, 500}); turn != 2 {
+		t.Errorf("crossed on turn %d, want 2", turn)
+	}
+	if turn := checkpointHitTurn(simPod{vx: 1500, mass: 1}, coast[:], point{2150, 800}); turn != 0 {
+		t.Errorf("crossed on turn %d a checkpoint the pod passes 800 from", turn)
+	}
+}
+
+func TestCutTheCurveDoesNotSkipCheckpoint(t *testing.T) {
+	cp, next := point{5000, 0}, point{5000, 5000}
+	if thrust, _ := cutTheCurve(gamer{angle: 0}, cp, next); thrust >= 0 {
+		t.Errorf("cuts to %v from standing 5000 away, at thrust %d", next, thrust)
+	}
+	if thrust, turn := cutTheCurve(gamer{x: 4000, vx: 800, angle: 0}, cp, next); thrust < 0 || turn != 1 {
+		t.Errorf("coming at 800 from 1000 away: thrust %d, turn %d", thrust, turn)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		g := gamer{x: rnd.Intn(8000), y: rnd.Intn(8000) - 4000, vx: rnd.Intn(1200) - 200, vy: rnd.Intn(800) - 400, angle: rnd.Intn(360)}
+		thrust, turn := cutTheCurve(g, cp, next)
+		if thrust < 0 {
+			continue
+		}
+		cmds := holdCommand(command{x: next.x, y: next.y, thrust: thrust})
+		if got := checkpointHitTurn(newSimPod(g), cmds[:], cp); got != turn || got == 0 {
+			t.Fatalf("%+v cuts at thrust %d, crossing on turn %d, holding it crosses on turn %d", g, thrust, turn, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+// The dataset command records the heuristics playing headless games against
+// themselves, to train and benchmark learned models on:
+//
+//	go run ./gold dataset -games 200 -out dataset.csv
+//
+// There is a row per pod and turn: the network's features of the pod, the
+// mode the heuristics moved it in, the action they took, and how the game
+// ended for its side.
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+)
+
+func init() {
+	localCommands["dataset"] = dataset
+}
+
+func datasetHeader() []string {
+	header := []string{"game", "turn", "side", "pod", "runner"}
+	for i := 0; i < nnInputs; i++ {
+		header = append(header, fmt.Sprintf("f%d", i))
+	}
+	return append(header, "mode", "rotation", "thrust", "shield", "boost", "won", "lead")
+}
+
+// recordGame plays one headless game of the heuristics and writes its rows.
+func recordGame(w *csv.Writer, game int, track map[int]*checkpoint) error {
+	var rows [][]string
+	record := func(turn, side int, state gameState, _ map[int]*checkpoint, cmds [2]command) {
+		runnerId := determineLeader(state.players)
+		opponents := [2]simPod{newSimPod(state.opponents[0]), newSimPod(state.opponents[1])}
+		for i, player := range state.players {
+			p := newSimPod(player)
+			features := podFeatures(p, i == runnerId, opponents, track)
+			action := actionFor(p, cmds[i])
+			row := []string{strconv.Itoa(game), strconv.Itoa(turn), strconv.Itoa(side), strconv.Itoa(i), strconv.FormatBool(i == runnerId)}
+			for _, f := range features {
+				row = append(row, strconv.FormatFloat(f, 'g', 6, 64))
+			}
+			row = append(row, state.modes[i], strconv.FormatFloat(action.rotation, 'f', 1, 64), strconv.Itoa(cmds[i].thrust),
+				strconv.FormatBool(cmds[i].shield), strconv.FormatBool(cmds[i].boost))
+			rows = append(rows, row)
+		}
+	}
+	heuristics := strategyTeam(strategyHeuristic, nil)
+	result := playGame(track, gameLaps, [2]team{heuristics, heuristics}, 0, record)
+	for _, row := range rows {
+		side, _ := strconv.Atoi(row[2])
+		lead := (result.progress[side] - result.progress[1-side]) / checkpointProgress
+		row = append(row, strconv.FormatBool(result.winner == side), strconv.FormatFloat(lead, 'f', 3, 64))
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDataset(out io.Writer, games int, seed int64) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(datasetHeader()); err != nil {
+		return err
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	for game := 0; game < games; game++ {
+		if err := recordGame(w, game, randomTrack(rnd)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func dataset(args []string) {
+	flags := flag.NewFlagSet("dataset", flag.ExitOnError)
+	games := flags.Int("games", 100, "games to record")
+	seed := flags.Int64("seed", 1, "seed of the tracks")
+	out := flags.String("out", "dataset.csv", "file the dataset is written to")
+	flags.Parse(args)
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	f, err := os.Create(*out)
+	if err == nil {
+		err = writeDataset(f, *games, *seed)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Println("cannot write the dataset:", err)
+		os.Exit(1)
+	}
+}
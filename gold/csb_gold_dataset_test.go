@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestDatasetRows(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeDataset(&out, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) < 2 {
+		t.Fatalf("got %d rows, want a header and at least one", len(rows))
+	}
+	modes := map[string]bool{modeNormal: true, modeAggro: true, modeDefense: true, modeDuel: true, modeTeam: true}
+	mode := len(datasetHeader()) - 7
+	for _, row := range rows[1:] {
+		if !modes[row[mode]] {
+			t.Fatalf("row %v has no mode", row)
+		}
+	}
+}
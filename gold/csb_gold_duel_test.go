@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// duelState has our blocker (players[1]) facing the opponent runner on its
+// way to checkpoint 1, 2200 away and coming at 600 a turn straight at it.
+func duelState() (gameState, map[int]*checkpoint) {
+	track := map[int]*checkpoint{
+		0: {center: point{1000, 5000}, longDistanceAimpoint: point{1000, 5000}},
+		1: {center: point{9000, 5000}, longDistanceAimpoint: point{9000, 5000}},
+	}
+	calculateAimpoints(track)
+	state := initGameState(track, 3)
+	state.first = false
+	state.players[0] = gamer{x: 2000, y: 1000, nextCheckPointId: 1, currentlap: 1, advancement: 10}
+	state.players[1] = gamer{x: 7000, y: 5000, angle: 180, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[0] = gamer{x: 4800, y: 5000, vx: 600, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[1] = gamer{x: 2000, y: 9000, nextCheckPointId: 1, currentlap: 1}
+	return state, track
+}
+
+// minimax is the duel's search without the pruning nor the ordering.
+func minimax(d *duel, r race, depth int) float64 {
+	best := math.Inf(-1)
+	for _, b := range duelBlockerActions {
+		worst := math.Inf(1)
+		for _, o := range duelRunnerActions {
+			next := d.turn(r, b, o)
+			value := next.score(d.track)
+			if depth > 1 {
+				value = minimax(d, next, depth-1)
+			}
+			worst = math.Min(worst, value)
+		}
+		best = math.Max(best, worst)
+	}
+	return best
+}
+
+func TestDuelSearchIsMinimax(t *testing.T) {
+	state, track := duelState()
+	root := newRace(state, 0)
+	for depth := 1; depth <= 2; depth++ {
+		d := duel{track: track, blocker: 1, runner: 2, deadline: time.Now().Add(time.Hour)}
+		got, action := d.search(root, depth, math.Inf(-1), math.Inf(1), nil)
+		if want := minimax(&d, root, depth); got != want {
+			t.Errorf("depth %d: alpha-beta %v, minimax %v", depth, got, want)
+		}
+		// the move found is worth what the search says, whatever the runner answers
+		worst := math.Inf(1)
+		for _, o := range duelRunnerActions {
+			next := d.turn(root, action, o)
+			value := next.score(track)
+			if depth > 1 {
+				value = minimax(&d, next, depth-1)
+			}
+			worst = math.Min(worst, value)
+		}
+		if worst != got {
+			t.Errorf("depth %d: %+v is worth %v, not %v", depth, action, worst, got)
+		}
+	}
+}
+
+func TestDuelBlocksHeadOn(t *testing.T) {
+	// Whichever way the runner swerves within the next two turns, it can't
+	// get past a blocker going full thrust straight at it.
+	state, track := duelState()
+	d := duel{track: track, blocker: 1, runner: 2, deadline: time.Now().Add(time.Hour)}
+	_, action := d.search(newRace(state, 0), 2, math.Inf(-1), math.Inf(1), nil)
+	if want := (podAction{0, 100, false, false}); action != want {
+		t.Errorf("the blocker plays %+v, want %+v", action, want)
+	}
+}
+
+func TestDuelOnlyWhenClose(t *testing.T) {
+	state, track := duelState()
+	state.opponents[0].x = 7000 - duelRange - 100
+	if cmd, ok := duelMove(state, track, 1, time.Now().Add(time.Second)); ok {
+		t.Errorf("the blocker duels %v with a runner out of range", cmd)
+	}
+}
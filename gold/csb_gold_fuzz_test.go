@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkOutput checks that every line the bot wrote is a command the referee
+// accepts: a target followed by a thrust in 0..100, BOOST or SHIELD.
+func checkOutput(t *testing.T, out []byte) {
+	t.Helper()
+	lines := bufio.NewScanner(bytes.NewReader(out))
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) != 3 {
+			t.Fatalf("command %q", lines.Text())
+		}
+		for _, f := range fields[:2] {
+			if _, err := strconv.Atoi(f); err != nil {
+				t.Fatalf("command %q has target %q", lines.Text(), f)
+			}
+		}
+		if fields[2] == "BOOST" || fields[2] == "SHIELD" {
+			continue
+		}
+		if thrust, err := strconv.Atoi(fields[2]); err != nil || thrust < 0 || thrust > 100 {
+			t.Fatalf("command %q has thrust %q", lines.Text(), fields[2])
+		}
+	}
+}
+
+func FuzzGameInput(f *testing.F) {
+	transcripts, _ := filepath.Glob(filepath.Join("testdata", "*.in"))
+	for _, transcript := range transcripts {
+		if data, err := ioutil.ReadFile(transcript); err == nil {
+			f.Add(data)
+		}
+	}
+	f.Add([]byte("3\n2\n1000 1000\n5000 5000\n1000 1000 0 0 0 7\n"))
+	f.Add([]byte("3\n0\n"))
+	f.Add([]byte("3\n-4\n"))
+	f.Add([]byte("1000 1000 8000 2000 7071 0\n3000 3000\n1000 1000 1000 1000 0 999\n"))
+	f.Add([]byte("3\n3\n0 0\n0 0\n0 0\n0 0 0 0 0 1\n0 0 0 0 0 1\n0 0 0 0 0 1\n0 0 0 0 0 1\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var out bytes.Buffer
+		runGame(bytes.NewReader(data), &out, 0)
+		checkOutput(t, out.Bytes())
+	})
+}
+
+// fuzzState makes a race on a random track out of the fuzzer's bytes, with
+// pods anywhere on and around the map and going at any speed a pod can.
+func fuzzState(data []byte) (gameState, map[int]*checkpoint) {
+	next := func() int {
+		if len(data) < 2 {
+			return 0
+		}
+		v := int(int16(uint16(data[0]) | uint16(data[1])<<8))
+		data = data[2:]
+		return v
+	}
+	track := randomTrack(rand.New(rand.NewSource(int64(next()))))
+	state := initGameState(track, 3)
+	state.first = next()%2 == 0
+	state.usedboost = next()%2 == 0
+	pods := []*gamer{&state.players[0], &state.players[1], &state.opponents[0], &state.opponents[1]}
+	for _, g := range pods {
+		*g = gamer{x: next(), y: next(), vx: next() / 16, vy: next() / 16, angle: (next() + 32768) % 360}
+		g.nextCheckPointId = (next() + 32768) % len(track)
+		g.currentlap = (next() + 32768) % 4
+		*g = observe(*g, g.x, g.y, g.vx, g.vy, g.angle, g.nextCheckPointId, track)
+	}
+	return state, track
+}
+
+func checkTarget(t *testing.T, decision string, targetV SmartVector, thrust int) {
+	t.Helper()
+	if math.IsNaN(targetV.x) || math.IsNaN(targetV.y) || math.IsInf(targetV.x, 0) || math.IsInf(targetV.y, 0) {
+		t.Fatalf("%s targets %+v", decision, targetV)
+	}
+	if thrust < 0 || thrust > 100 {
+		t.Fatalf("%s thrusts %d", decision, thrust)
+	}
+}
+
+func FuzzDecisions(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0, 0, 0, 0, 0, 0x88, 0x13, 0x88, 0x13, 0, 1, 0, 0, 10, 0, 1, 0, 0, 0})
+	f.Add([]byte("a pod at rest on top of another one, at rest as well"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		state, track := fuzzState(data)
+		leaderId := determineLeader(state.players)
+		for playerId, player := range state.players {
+			cmd, mode := movePlayer(playerId, playerId == leaderId, state, track, time.Now())
+			checkOutput(t, []byte(cmd.String()))
+			if mode == "" {
+				t.Fatalf("pod %d moved without a mode", playerId)
+			}
+
+			cp := track[player.nextCheckPointId]
+			toV := func(p point) SmartVector {
+				return NewSmartVectorCartesian(float64(p.x-player.x), float64(p.y-player.y))
+			}
+			toCheckpointV, toLongDistanceAimV, toNextAimpointV := toV(cp.center), toV(cp.longDistanceAimpoint), toV(cp.nextAimpoint)
+			toOpponent0V := toV(point{state.opponents[0].x, state.opponents[0].y})
+			toOpponent1V := toV(point{state.opponents[1].x, state.opponents[1].y})
+			angle := normalizeAngleDegrees(int(toCheckpointV.angleDegrees()) - player.angle)
+
+			targetV, thrust := normalMove(player, toCheckpointV, toLongDistanceAimV, toNextAimpointV)
+			checkTarget(t, "normalMove", targetV, thrust)
+			targetV, thrust = aggroMove(player, angle, toCheckpointV, toLongDistanceAimV, toNextAimpointV, int(toCheckpointV.length()), toOpponent0V, toOpponent1V)
+			checkTarget(t, "aggroMove", targetV, thrust)
+			targetV, thrust = fullDefenseMode(player, track, state.opponents)
+			checkTarget(t, "fullDefenseMode", targetV, thrust)
+		}
+		for _, cmd := range heuristicMove(&state, track, leaderId, time.Now()) {
+			checkOutput(t, []byte(cmd.String()))
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with what the bot outputs now")
+
+// TestGoldenTurns feeds the transcripts in testdata to the bot and compares
+// its commands with the golden files next to them. The searches get no time
+// to think, so the goldens pin down the heuristics. After a change of
+// behavior that is meant, rewrite them with
+//
+//	go test ./gold -run TestGoldenTurns -update
+func TestGoldenTurns(t *testing.T) {
+	transcripts, err := filepath.Glob(filepath.Join("testdata", "*.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transcript := range transcripts {
+		t.Run(filepath.Base(transcript), func(t *testing.T) {
+			in, err := os.Open(transcript)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			var out bytes.Buffer
+			if err := runGame(in, &out, 0); err != nil {
+				t.Fatal(err)
+			}
+			golden := strings.TrimSuffix(transcript, ".in") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareCommands(t, strings.Split(string(want), "\n"), strings.Split(out.String(), "\n"))
+		})
+	}
+}
+
+// compareCommands reports the turns on which the bot's commands changed,
+// two lines a turn.
+func compareCommands(t *testing.T, want, got []string) {
+	t.Helper()
+	changed := 0
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			if changed++; changed <= 20 {
+				t.Errorf("turn %d, pod %d: got %q, want %q", i/2+1, i%2, g, w)
+			}
+		}
+	}
+	if changed > 20 {
+		t.Errorf("and %d more changed commands", changed-20)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+// Headless games: the referee's rules played out with the simulation, so
+// bots can race each other locally without the CodinGame servers.
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	gameLaps        = 3
+	gameMaxTurns    = 600
+	checkpointLimit = 100 // turns a team may go without passing a checkpoint
+)
+
+// team plays one side of a headless game, from the state the bot would have
+// read from its input.
+type team func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command
+
+// strategyTeam plays strategy s, the neural one driven by net.
+func strategyTeam(s string, net *network) team {
+	return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+		return playStrategy(s, net, state, track, deadline)
+	}
+}
+
+// gameResult is how a headless game ended. progress is the best progress
+// of each side's pods.
+type gameResult struct {
+	winner   int
+	turns    int
+	progress [2]float64
+}
+
+// turnObserver is shown each side's state, the track as the side knows it,
+// and its commands every turn of a headless game.
+type turnObserver func(turn, side int, state gameState, track map[int]*checkpoint, cmds [2]command)
+
+// randomTrack is a track like the referee makes them: 3 to 8 checkpoints
+// spread over the map.
+func randomTrack(rnd *rand.Rand) map[int]*checkpoint {
+	count := 3 + rnd.Intn(6)
+	track := make(map[int]*checkpoint)
+	for len(track) < count {
+		center := point{1000 + rnd.Intn(14000), 1000 + rnd.Intn(7000)}
+		apart := true
+		for _, cp := range track {
+			if math.Hypot(float64(cp.center.x-center.x), float64(cp.center.y-center.y)) < 2500 {
+				apart = false
+			}
+		}
+		if apart {
+			track[len(track)] = &checkpoint{center: center, longDistanceAimpoint: center}
+		}
+	}
+	calculateAimpoints(track)
+	return track
+}
+
+// startingGrid lines the four pods up across the first checkpoint, facing
+// the second one, side 0's pods on the left.
+func startingGrid(track map[int]*checkpoint) [4]simPod {
+	start, next := track[0].center, track[1].center
+	dx, dy := float64(next.x-start.x), float64(next.y-start.y)
+	length := math.Hypot(dx, dy)
+	angle := AngleRadians(math.Atan2(dy, dx)).Round()
+	var pods [4]simPod
+	for i := range pods {
+		offset := (float64(i) - 1.5) * 1000
+		pods[i] = simPod{
+			x:                math.Round(float64(start.x) - dy/length*offset),
+			y:                math.Round(float64(start.y) + dx/length*offset),
+			angle:            angle,
+			mass:             1,
+			nextCheckPointId: 1,
+		}
+	}
+	return pods
+}
+
+// playGame races side 0 against side 1 on the track, by the gold league's
+// rules. Each team gets budget per turn, and observe, if not nil, is shown
+// every turn.
+func playGame(track map[int]*checkpoint, laps int, sides [2]team, budget time.Duration, observe turnObserver) gameResult {
+	return playLeagueGame(track, laps, sides, rulesOf[leagueGold], budget, observe)
+}
+
+// playLeagueGame is playGame by the given rules. Each side races its first
+// rules.pods pods of the starting grid; teams sent the single pod protocol
+// see the race as the bot would make it out of that.
+func playLeagueGame(track map[int]*checkpoint, laps int, sides [2]team, rules leagueRules, budget time.Duration, observe turnObserver) gameResult {
+	return newHeadlessGame(track, laps, rules).play(sides, budget, observe)
+}
+
+// headlessGame is a headless game under way.
+type headlessGame struct {
+	track   map[int]*checkpoint
+	laps    int
+	rules   leagueRules
+	pods    []simPod     // side 0's pods, then side 1's
+	states  [2]gameState // the sides' states at the start of the game or the fork
+	boosted [4]bool
+	idle    [2]int // turns each side went without passing a checkpoint
+	result  gameResult
+}
+
+// newHeadlessGame lines the pods up on the starting grid.
+func newHeadlessGame(track map[int]*checkpoint, laps int, rules leagueRules) *headlessGame {
+	n := rules.pods
+	grid := startingGrid(track)
+	r := &headlessGame{
+		track:  track,
+		laps:   laps,
+		rules:  rules,
+		pods:   append(append([]simPod{}, grid[:n]...), grid[2:2+n]...),
+		states: [2]gameState{initGameState(track, laps), initGameState(track, laps)},
+		result: gameResult{winner: -1},
+	}
+	if !rules.fullInput {
+		r.states = [2]gameState{initGameState(nil, singlePodLaps), initGameState(nil, singlePodLaps)}
+	}
+	return r
+}
+
+// play plays on until a side wins or time is up, the sides as bots in
+// process starting from r.states.
+func (r *headlessGame) play(sides [2]team, budget time.Duration, observe turnObserver) gameResult {
+	var bots [2]*teamBot
+	for side := range bots {
+		bots[side] = newTeamBot(sides[side], budget, r.rules)
+		bots[side].Init(r.laps, r.track)
+		bots[side].state = r.states[side]
+	}
+	return r.run(func(side int) [2]command {
+		b := bots[side]
+		ours := b.Turn(r.podStates(side))
+		if observe != nil {
+			observe(r.result.turns, side, b.state, b.seen, ours)
+		}
+		return ours
+	})
+}
+
+// run plays on until a side wins or time is up, each side's commands of a
+// turn being decided by decide.
+func (r *headlessGame) run(decide func(side int) [2]command) gameResult {
+	n := r.rules.pods
+	finish := r.laps * len(r.track)
+	for r.result.turns < gameMaxTurns && r.result.winner < 0 {
+		r.result.turns++
+		var cmds [4]command
+		for side := 0; side < 2; side++ {
+			ours := decide(side)
+			for i := 0; i < n; i++ {
+				cmds[n*side+i] = ours[i].allowed(r.rules)
+			}
+		}
+		for i := range r.pods {
+			if cmds[i].boost {
+				if r.boosted[i] {
+					cmds[i].boost, cmds[i].thrust = false, 100
+				}
+				r.boosted[i] = true
+			}
+		}
+		var passed [4]int
+		for i := range r.pods {
+			passed[i] = r.pods[i].passed
+		}
+		simulateLeagueTurn(r.pods, cmds[:len(r.pods)], r.track, r.rules)
+		for side := 0; side < 2; side++ {
+			r.idle[side]++
+			for i := n * side; i < n*side+n; i++ {
+				if r.pods[i].passed > passed[i] {
+					r.idle[side] = 0
+				}
+				if r.pods[i].passed >= finish && r.result.winner < 0 {
+					r.result.winner = side
+				}
+			}
+			if r.idle[side] > checkpointLimit && r.result.winner < 0 {
+				r.result.winner = 1 - side
+			}
+		}
+	}
+	r.result.progress = r.progress()
+	if r.result.winner < 0 {
+		r.result.winner = 0
+		if r.result.progress[1] > r.result.progress[0] {
+			r.result.winner = 1
+		}
+	}
+	return r.result
+}
+
+// progress is the best progress of each side's pods.
+func (r *headlessGame) progress() [2]float64 {
+	n := r.rules.pods
+	var progress [2]float64
+	for side := range progress {
+		progress[side] = r.pods[n*side].progress(r.track)
+		for i := n * side; i < n*side+n; i++ {
+			progress[side] = math.Max(progress[side], r.pods[i].progress(r.track))
+		}
+	}
+	return progress
+}
+
+// simulateLeagueTurn is simulateTurn by the given rules: without
+// collisions the pods move through each other.
+func simulateLeagueTurn(pods []simPod, cmds []command, track map[int]*checkpoint, rules leagueRules) {
+	if rules.collisions {
+		simulateTurn(pods, cmds, track)
+		return
+	}
+	for i := range pods {
+		pods[i].apply(cmds[i])
+		pods[i].move(1, track)
+		pods[i].endTurn()
+	}
+}
+
+// singlePodInput is what the single pod protocol sends the side of player
+// about the race.
+func singlePodInput(player, opponent gamer, track map[int]*checkpoint) singlePodTurn {
+	cp := track[player.nextCheckPointId].center
+	dx, dy := float64(cp.x-player.x), float64(cp.y-player.y)
+	angle := AngleDegrees(float64(player.angle)).Turn(AngleRadians(math.Atan2(dy, dx)))
+	return singlePodTurn{
+		x:                   player.x,
+		y:                   player.y,
+		nextCheckpointX:     cp.x,
+		nextCheckpointY:     cp.y,
+		nextCheckpointDist:  int(math.Hypot(dx, dy)),
+		nextCheckpointAngle: int(math.Round(angle)),
+		opponentX:           opponent.x,
+		opponentY:           opponent.y,
+	}
+}
+
+// podGamer is the pod as the bot reads it from its input.
+func podGamer(prev gamer, p simPod, track map[int]*checkpoint) gamer {
+	return observe(prev, int(p.x), int(p.y), int(p.vx), int(p.vy), int(p.angle.Degrees()), p.nextCheckPointId, track)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Stderr, _ = os.Open(os.DevNull)
+	// The tests of processBot run the test binary as the bot of a league.
+	if l := os.Getenv("CSB_TEST_BOT_LEAGUE"); l != "" {
+		league = l
+		runGame(os.Stdin, os.Stdout, 0)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestNetworkLiteralRoundTrip(t *testing.T) {
+	if literal := encodeNetwork(policyNet.weights[:]); literal != nnWeightsLiteral {
+		t.Errorf("encoding the decoded network gives %q, want %q", literal, nnWeightsLiteral)
+	}
+}
+
+func TestHeadlessGameFinishes(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	sides := [2]team{strategyTeam(strategyHeuristic, nil), strategyTeam(strategyHeuristic, nil)}
+	result := playGame(track, gameLaps, sides, 0, nil)
+	if result.turns >= gameMaxTurns {
+		t.Errorf("game still running after %d turns", result.turns)
+	}
+	if finish := float64(gameLaps*len(track)) * checkpointProgress; result.progress[result.winner] < finish-checkpointProgress {
+		t.Errorf("winner only got to %.0f of %.0f", result.progress[result.winner], finish)
+	}
+	t.Logf("%+v on %d checkpoints", result, len(track))
+}
@@ -0,0 +1,264 @@
+package main
+
+// The import command turns the replay of a game played on CodinGame, the
+// JSON the browser downloads, into a transcript the bot can be run on again:
+//
+//	go run ./gold import -replay lost.json -out gold/testdata/lost1
+//
+// writes lost1.in, the input our bot was sent; lost1.golden, the commands
+// it answered; and lost1.stderr, the rest of its debug output, turn by turn.
+// In gold/testdata the game becomes one of the golden tests.
+//
+// The input is rebuilt from the frames' views, which draw the race: the
+// first has the laps and the checkpoints, and each view ends with the pods
+// at the start of the turn, player 0's then player 1's, one per line as
+// "X Y VX VY ANGLE NEXT_CHECKPOINT_ID". Two pods make a game of the single
+// pod protocol, four one of the gold league. A replay whose views don't
+// read that way falls back on the input the bot echoed to stderr, which a
+// submission only does with echoInput on.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func init() {
+	localCommands["import"] = importCommand
+}
+
+// replayFrame is a frame of a CodinGame replay: what an agent wrote during
+// a turn, agent -1 for the frames no agent played.
+type replayFrame struct {
+	AgentID int    `json:"agentId"`
+	Stdout  string `json:"stdout"`
+	Stderr  string `json:"stderr"`
+	View    string `json:"view"`
+}
+
+type replayAgent struct {
+	Index      int `json:"index"`
+	Codingamer struct {
+		Pseudo string `json:"pseudo"`
+	} `json:"codingamer"`
+}
+
+// replayFile is a CodinGame replay, as saved from the browser, either bare
+// or wrapped the way the site's services answer.
+type replayFile struct {
+	Frames  []replayFrame `json:"frames"`
+	Agents  []replayAgent `json:"agents"`
+	Success *replayFile   `json:"success"`
+}
+
+// importedGame is our side of a replay.
+type importedGame struct {
+	player   string   // our pseudo, when the replay has it
+	input    string   // the input we were sent
+	commands []string // the commands we answered
+	stderr   []string // the rest of our stderr, by turn
+}
+
+// importReplay reads the replay of a game and returns agent's side of it.
+// With agent -1 our agent is the one that echoed its input, a replay
+// without the echo has to say.
+func importReplay(r io.Reader, agent int) (importedGame, error) {
+	var replay replayFile
+	if err := json.NewDecoder(r).Decode(&replay); err != nil {
+		return importedGame{}, err
+	}
+	if replay.Success != nil {
+		replay = *replay.Success
+	}
+	if agent < 0 {
+		found, err := echoingAgent(replay.Frames)
+		if err != nil {
+			return importedGame{}, fmt.Errorf("%v; with the views alone, pick our agent with -agent", err)
+		}
+		agent = found
+	}
+
+	var game importedGame
+	for _, a := range replay.Agents {
+		if a.Index == agent {
+			game.player = a.Codingamer.Pseudo
+		}
+	}
+	var echoed strings.Builder
+	for _, frame := range replay.Frames {
+		if frame.AgentID != agent {
+			continue
+		}
+		var debug strings.Builder
+		for _, line := range splitLines(frame.Stderr) {
+			if strings.HasPrefix(line, echoPrefix) {
+				echoed.WriteString(strings.TrimPrefix(line, echoPrefix) + "\n")
+			} else {
+				debug.WriteString(line + "\n")
+			}
+		}
+		game.commands = append(game.commands, splitLines(frame.Stdout)...)
+		game.stderr = append(game.stderr, debug.String())
+	}
+	input, err := viewTranscript(replay.Frames, agent)
+	if err == nil {
+		err = checkTranscript(input)
+	}
+	if err != nil {
+		if echoed.Len() == 0 {
+			return game, fmt.Errorf("the views of agent %d's turns: %v, and it echoed no input", agent, err)
+		}
+		input = echoed.String()
+		if err := checkTranscript(input); err != nil {
+			return game, fmt.Errorf("the input agent %d echoed: %v", agent, err)
+		}
+	}
+	game.input = input
+	return game, nil
+}
+
+// viewTranscript rebuilds the input agent was sent from the views of the
+// frames of its turns.
+func viewTranscript(frames []replayFrame, agent int) (string, error) {
+	if agent != 0 && agent != 1 {
+		return "", fmt.Errorf("no agent %d in a race of two", agent)
+	}
+	var header, turns strings.Builder
+	var track map[int]*checkpoint
+	pods, turn := 0, 0
+	for _, frame := range frames {
+		if frame.AgentID != agent {
+			continue
+		}
+		turn++
+		view := strings.NewReader(frame.View)
+		if track == nil {
+			var laps int
+			if _, err := fmt.Fscan(view, &laps); err != nil || laps < 1 {
+				return "", fmt.Errorf("no laps in the first view")
+			}
+			var err error
+			if track, err = readTrack(view); err != nil {
+				return "", fmt.Errorf("no track in the first view: %v", err)
+			}
+			writeGoldHeader(&header, track, laps)
+		}
+		var seen []gamer
+		for {
+			var g gamer
+			if _, err := fmt.Fscan(view, &g.x, &g.y, &g.vx, &g.vy, &g.angle, &g.nextCheckPointId); err == io.EOF {
+				break
+			} else if err != nil {
+				return "", fmt.Errorf("turn %d: %v", turn, err)
+			}
+			if track[g.nextCheckPointId] == nil {
+				return "", fmt.Errorf("turn %d: a pod goes for checkpoint %d, which isn't on the track", turn, g.nextCheckPointId)
+			}
+			seen = append(seen, g)
+		}
+		if pods == 0 {
+			pods = len(seen)
+		}
+		if len(seen) != pods || pods != 2 && pods != 4 {
+			return "", fmt.Errorf("turn %d: %d pods in the view", turn, len(seen))
+		}
+		n := pods / 2
+		var state gameState
+		copy(state.players[:], seen[n*agent:n*agent+n])
+		copy(state.opponents[:], seen[n*(1-agent):n*(1-agent)+n])
+		if pods == 2 {
+			writeBronzeTurn(&turns, state, track)
+		} else {
+			writeGoldTurn(&turns, state)
+		}
+	}
+	if track == nil {
+		return "", fmt.Errorf("agent %d played no turn", agent)
+	}
+	if pods == 2 {
+		// the single pod protocol starts with the first turn
+		return turns.String(), nil
+	}
+	return header.String() + turns.String(), nil
+}
+
+// echoingAgent is the only agent of the frames that echoed its input.
+func echoingAgent(frames []replayFrame) (int, error) {
+	agent := -1
+	for _, frame := range frames {
+		if frame.AgentID < 0 || frame.AgentID == agent || !strings.Contains("\n"+frame.Stderr, "\n"+echoPrefix) {
+			continue
+		}
+		if agent >= 0 {
+			return -1, errors.New("both agents echoed their input, a game against ourselves? Pick one with -agent")
+		}
+		agent = frame.AgentID
+	}
+	if agent < 0 {
+		return -1, errors.New("no agent echoed its input, was the bot submitted with echoInput on?")
+	}
+	return agent, nil
+}
+
+// checkTranscript checks that the transcript is a whole game the bot can
+// read, in any league's protocol.
+func checkTranscript(transcript string) error {
+	return runGame(strings.NewReader(transcript), ioutil.Discard, 0)
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// writeImport writes the game to the transcript, golden and stderr files of
+// prefix.
+func writeImport(prefix string, game importedGame) error {
+	if err := ioutil.WriteFile(prefix+".in", []byte(game.input), 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(prefix+".golden", []byte(strings.Join(game.commands, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	for turn, debug := range game.stderr {
+		fmt.Fprintf(&stderr, "--- turn %d\n%s", turn+1, debug)
+	}
+	return ioutil.WriteFile(prefix+".stderr", []byte(stderr.String()), 0644)
+}
+
+func importCommand(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	replay := flags.String("replay", "replay.json", "replay saved from CodinGame")
+	agent := flags.Int("agent", -1, "index of our agent in the replay, -1 for the one that echoed its input when one did")
+	out := flags.String("out", "replay", "prefix of the files written")
+	flags.Parse(args)
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	f, err := os.Open(*replay)
+	if err != nil {
+		fmt.Println("cannot read the replay:", err)
+		os.Exit(1)
+	}
+	game, err := importReplay(f, *agent)
+	f.Close()
+	if err == nil {
+		err = writeImport(*out, game)
+	}
+	if err != nil {
+		fmt.Println("cannot import the replay:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d turns of %q imported to %s.in\n", len(game.stderr), game.player, *out)
+}
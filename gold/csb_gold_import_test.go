@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// playedReplay plays the transcript like CodinGame would, and returns the
+// replay of the game with ours as agent 1, echoing its input if echo.
+func playedReplay(t *testing.T, transcript string, echo bool) []byte {
+	stderr, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stderr.Name())
+	silenced := os.Stderr
+	os.Stderr, echoInput = stderr, echo
+	var stdout bytes.Buffer
+	err = runGame(strings.NewReader(transcript), &stdout, 0)
+	os.Stderr, echoInput = silenced, false
+	stderr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug, err := ioutil.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var replay replayFile
+	replay.Frames = []replayFrame{{AgentID: -1}}
+	for i, line := range splitLines(stdout.String()) {
+		frame := replayFrame{AgentID: 1, Stdout: line + "\n"}
+		if i == 0 {
+			frame.Stderr = string(debug)
+		}
+		replay.Frames = append(replay.Frames, replayFrame{AgentID: 0, Stdout: "8000 4500 100\n", Stderr: "not ours\n"}, frame)
+	}
+	replay.Agents = make([]replayAgent, 2)
+	replay.Agents[1].Index = 1
+	replay.Agents[1].Codingamer.Pseudo = "us"
+	data, err := json.Marshal(map[string]replayFile{"success": replay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestImportReplay(t *testing.T) {
+	transcript, err := ioutil.ReadFile(filepath.Join("testdata", "seed1.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", "seed1.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := importReplay(bytes.NewReader(playedReplay(t, string(transcript), true)), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.player != "us" {
+		t.Errorf("imported the game of %q", game.player)
+	}
+	if game.input != string(transcript) {
+		t.Errorf("imported input differs from the transcript")
+	}
+	if got := strings.Join(game.commands, "\n") + "\n"; got != string(golden) {
+		t.Errorf("imported commands differ from the golden file")
+	}
+	if strings.Contains(strings.Join(game.stderr, ""), echoPrefix+"3\n") {
+		t.Errorf("the echoed input was left in the debug output")
+	}
+
+	if _, err := importReplay(bytes.NewReader(playedReplay(t, string(transcript), true)), 0); err == nil {
+		t.Errorf("imported the opponent, which echoed nothing")
+	}
+	if _, err := importReplay(bytes.NewReader(playedReplay(t, string(transcript), false)), -1); err == nil {
+		t.Errorf("imported a game played without echoing the input")
+	}
+	if _, err := importReplay(strings.NewReader(`{"frames": [{"agentId": 0, "stderr": "> 3\n> 2\n"}]}`), -1); err == nil {
+		t.Errorf("imported a game that ends before it starts")
+	}
+}
+
+// viewedReplay is the replay of the game of the transcript as CodinGame
+// draws it, with ours as agent 1 and each turn's stderr in its frame.
+func viewedReplay(t *testing.T, transcript string, commands []string) []byte {
+	read, state, _, err := newTurnReader(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replay replayFile
+	replay.Frames = []replayFrame{{AgentID: -1}}
+	for turn := 0; ; turn++ {
+		track, err := read(&state)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		var view strings.Builder
+		if turn == 0 {
+			writeGoldHeader(&view, track, state.numlaps)
+		}
+		writeGoldTurn(&view, gameState{players: state.opponents, opponents: state.players})
+		ours := replayFrame{AgentID: 1, Stdout: commands[2*turn] + "\n" + commands[2*turn+1] + "\n", Stderr: fmt.Sprintf("turn %d\n", turn+1), View: view.String()}
+		replay.Frames = append(replay.Frames, replayFrame{AgentID: 0, Stdout: "8000 4500 100\n8000 4500 100\n", Stderr: "not ours\n", View: view.String()}, ours)
+	}
+	data, err := json.Marshal(map[string]replayFile{"success": replay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestImportReplayViews(t *testing.T) {
+	transcript, err := ioutil.ReadFile(filepath.Join("testdata", "seed1.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", "seed1.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := splitLines(string(golden))
+	replay := viewedReplay(t, string(transcript), commands)
+	game, err := importReplay(bytes.NewReader(replay), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.input != string(transcript) {
+		t.Errorf("the input rebuilt from the views differs from the transcript")
+	}
+	if !reflect.DeepEqual(game.commands, commands) {
+		t.Errorf("imported commands differ from the golden file")
+	}
+	for turn, debug := range game.stderr {
+		if want := fmt.Sprintf("turn %d\n", turn+1); debug != want {
+			t.Fatalf("turn %d has the stderr %q, want %q", turn+1, debug, want)
+		}
+	}
+	if _, err := importReplay(bytes.NewReader(replay), -1); err == nil {
+		t.Errorf("imported a replay without the echo, not told which agent is ours")
+	}
+}
+
+func TestViewTranscriptSinglePod(t *testing.T) {
+	frames := []replayFrame{
+		{AgentID: 0, View: "3\n2\n8000 2000\n1000 1000\n1000 1000 0 0 0 0\n3000 3000 0 0 0 0\n"},
+		{AgentID: 0, View: "1100 1000 85 0 0 0\n3000 3100 0 85 90 0\n"},
+	}
+	got, err := viewTranscript(frames, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1000 1000 8000 2000 7071 8\n3000 3000\n1100 1000 8000 2000 6972 8\n3000 3100\n"; got != want {
+		t.Errorf("transcript %q, want %q", got, want)
+	}
+	if err := checkTranscript(got); err != nil {
+		t.Error(err)
+	}
+	frames[1].View = "1100 1000 85 0 0 0\n"
+	if _, err := viewTranscript(frames, 0); err == nil {
+		t.Error("rebuilt a turn missing the opponent's pod")
+	}
+}
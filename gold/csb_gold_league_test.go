@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestTrackLearner(t *testing.T) {
+	cp0, cp1, cp2 := point{1000, 1000}, point{8000, 2000}, point{5000, 7000}
+	var l trackLearner
+	for i, target := range []point{cp1, cp1, cp2, cp0} {
+		track, id := l.learn(target)
+		if l.known {
+			t.Fatalf("track known after %d turns, before lap 1 is over", i+1)
+		}
+		if track[id].center != target {
+			t.Fatalf("sent to %v, got checkpoint %d at %v", target, id, track[id].center)
+		}
+		if track[id].nextAimpoint != target {
+			t.Errorf("aims past %v before knowing what comes next", target)
+		}
+	}
+	track, id := l.learn(cp1)
+	if !l.known || len(track) != 3 || id != 0 {
+		t.Fatalf("back to %v: known %t, %d checkpoints, id %d", cp1, l.known, len(track), id)
+	}
+	for id, want := range []point{cp1, cp2, cp0} {
+		if track[id].center != want {
+			t.Errorf("checkpoint %d at %v, want %v", id, track[id].center, want)
+		}
+	}
+	if track[2].nextAimpoint != cp1 {
+		t.Errorf("after %v aim at %v, want %v", cp0, track[2].nextAimpoint, cp1)
+	}
+	if track, id := l.learn(cp0); len(track) != 3 || id != 2 {
+		t.Errorf("going on to %v: %d checkpoints, id %d", cp0, len(track), id)
+	}
+	if _, id := l.learn(point{1, 1}); l.known || id != 0 {
+		t.Errorf("a checkpoint off the track: known %t, id %d", l.known, id)
+	}
+}
+
+func TestAllowedCommands(t *testing.T) {
+	boost := command{x: 1, y: 2, thrust: 30, boost: true}
+	shield := command{x: 1, y: 2, thrust: 30, shield: true}
+	if got := boost.allowed(rulesOf[leagueWood]); got.String() != "1 2 100" {
+		t.Errorf("BOOST in wood is %q", got)
+	}
+	if got := shield.allowed(rulesOf[leagueWood]); got.String() != "1 2 30" {
+		t.Errorf("SHIELD in wood is %q", got)
+	}
+	if got := boost.allowed(rulesOf[leagueBronze]); got != boost {
+		t.Errorf("BOOST in bronze is %q", got)
+	}
+	if got := shield.allowed(rulesOf[leagueGold]); got != shield {
+		t.Errorf("SHIELD in gold is %q", got)
+	}
+}
+
+func TestSinglePodProtocol(t *testing.T) {
+	in := "1000 1000 8000 2000 7071 0\n3000 3000\n" +
+		"1100 1000 8000 2000 6971 -10\n3000 3100\n"
+	var out bytes.Buffer
+	if err := runGame(strings.NewReader(in), &out, 0); err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out.Bytes())
+	if lines := strings.Count(out.String(), "\n"); lines != 2 {
+		t.Errorf("%d commands for 2 turns:\n%s", lines, out.String())
+	}
+}
+
+func TestSinglePodAdapterEstimates(t *testing.T) {
+	var a singlePodAdapter
+	state := gameState{first: true}
+	a.read(&state, singlePodTurn{1000, 1000, 8000, 2000, 7071, 0, 3000, 3000})
+	state.first = false
+	a.read(&state, singlePodTurn{1100, 1000, 8000, 2000, 6971, -10, 3000, 3100})
+	// the checkpoint is 8 degrees off east, and we face 10 degrees past it
+	if p := state.players[0]; p.vx != 85 || p.vy != 0 || p.angle != 18 {
+		t.Errorf("our pod is estimated %+v", p)
+	}
+	if o := state.opponents[0]; o.vx != 0 || o.vy != 85 || o.angle != 90 {
+		t.Errorf("the opponent is estimated %+v", o)
+	}
+}
+
+func TestLeagueGamesFinish(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(2)))
+	heuristics := strategyTeam(strategyHeuristic, nil)
+	for _, l := range []string{leagueWood, leagueBronze, leagueSilver, leagueGold} {
+		rules := rulesOf[l]
+		var forbidden []command
+		result := playLeagueGame(track, gameLaps, [2]team{heuristics, heuristics}, rules, 0, func(turn, side int, state gameState, seen map[int]*checkpoint, cmds [2]command) {
+			if !rules.fullInput && (state.players[1].x != parkedPod.x || len(seen) > len(track)) {
+				t.Fatalf("%s, turn %d: side %d sees %+v on %d checkpoints", l, turn, side, state.players, len(seen))
+			}
+			for _, cmd := range cmds[:rules.pods] {
+				if cmd != cmd.allowed(rules) {
+					forbidden = append(forbidden, cmd)
+				}
+			}
+		})
+		if result.turns >= gameMaxTurns {
+			t.Errorf("%s: game still running after %d turns", l, result.turns)
+		}
+		if l == leagueWood && len(forbidden) == 0 {
+			t.Errorf("%s: the heuristics never tried a BOOST or SHIELD the referee had to refuse", l)
+		}
+		t.Logf("%s: %+v", l, result)
+	}
+}
+
+func TestNoCollisions(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	pods := []simPod{{x: 5000, y: 5000, vx: 400, mass: 1}, {x: 6000, y: 5000, vx: -400, angle: AngleDegrees(180), mass: 1}}
+	cmds := []command{{x: 9000, y: 5000}, {x: 1000, y: 5000}}
+	simulateLeagueTurn(pods, cmds, track, rulesOf[leagueWood])
+	if pods[0].x != 5400 || pods[1].x != 5600 {
+		t.Errorf("without collisions the pods got to %.0f and %.0f", pods[0].x, pods[1].x)
+	}
+}
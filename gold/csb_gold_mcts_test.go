@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMCTSGoesForTheCheckpoint(t *testing.T) {
+	defer func(r *rand.Rand) { searchRand = r }(searchRand)
+	searchRand = rand.New(rand.NewSource(1))
+
+	track := map[int]*checkpoint{
+		0: {center: point{1000, 1000}, longDistanceAimpoint: point{1000, 1000}},
+		1: {center: point{12000, 5000}, longDistanceAimpoint: point{12000, 5000}},
+	}
+	calculateAimpoints(track)
+	state := initGameState(track, 3)
+	state.first = false
+	// our runner faces the checkpoint, 10000 away, everybody else is far
+	state.players[0] = gamer{x: 2000, y: 5000, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	state.players[1] = gamer{x: 2000, y: 8500, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[0] = gamer{x: 1000, y: 1000, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[1] = gamer{x: 14000, y: 1000, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	root := newRace(state, 0)
+	root.boostAllowed = false
+
+	action, iterations := mctsSearchUntil(root, 0, track, func(iterations int) bool { return iterations == 2000 })
+	if iterations != 2000 {
+		t.Fatalf("searched %d iterations, want 2000", iterations)
+	}
+	if action.thrust != 100 || action.shield || math.Abs(action.rotation) > 9 {
+		t.Errorf("facing the checkpoint, the search plays %+v", action)
+	}
+}
+
+func TestMCTSPickTriesEveryActionFirst(t *testing.T) {
+	n := &mctsNode{}
+	tried := map[int]bool{}
+	for k := 0; k < len(mctsActions)-1; k++ {
+		a := n.pick(false)
+		if a < 0 || tried[a] || mctsActions[a].boost {
+			t.Fatalf("picked %d after trying %v, the boost being used", a, tried)
+		}
+		tried[a] = true
+		n.children[a] = &mctsNode{visits: 1}
+		n.visits++
+	}
+	if a := n.pick(false); !tried[a] {
+		t.Errorf("picked %d once all actions but the boost were tried", a)
+	}
+}
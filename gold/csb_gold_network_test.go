@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// networkTrack has checkpoint 1 5000 away from the pod at the origin facing
+// east, at an angle of cpAngle.
+func networkTrack(cpAngle float64) map[int]*checkpoint {
+	sin, cos := math.Sincos(cpAngle * math.Pi / 180)
+	cp := point{int(math.Round(5000 * cos)), int(math.Round(5000 * sin))}
+	track := map[int]*checkpoint{
+		0: {center: point{-5000, 0}, longDistanceAimpoint: point{-5000, 0}},
+		1: {center: cp, longDistanceAimpoint: cp},
+	}
+	calculateAimpoints(track)
+	return track
+}
+
+func TestNetworkForward(t *testing.T) {
+	// hidden neuron 0 is tanh(2 in[1] - 1), output 0 is 3 times it plus 0.5
+	// and output 1 is the bias alone, the other weights are zero
+	var n network
+	n.weights[1], n.weights[nnInputs] = 2, -1
+	out := nnHidden * (nnInputs + 1)
+	n.weights[out], n.weights[out+nnHidden] = 3, 0.5
+	n.weights[out+nnHidden+1+nnHidden] = -0.25
+	in := [nnInputs]float64{1: 0.75, 4: 9}
+	got := n.forward(&in)
+	want := [nnOutputs]float64{3*math.Tanh(0.5) + 0.5, -0.25}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("output %d is %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNetworkAct(t *testing.T) {
+	// steering by the checkpoint's side, 5000 away at 30 degrees to the
+	// right gives in[1] = 0.25: rotation 18 tanh(tanh(0.25)), and the thrust
+	// bias of ln 3 gives 100/(1+1/3)
+	var n network
+	n.weights[1] = 1
+	out := nnHidden * (nnInputs + 1)
+	n.weights[out] = 1
+	n.weights[out+nnHidden+1+nnHidden] = math.Log(3)
+	p := simPod{angle: AngleDegrees(0), mass: 1, nextCheckPointId: 1}
+	track := networkTrack(30)
+	got := n.act(p, true, [2]simPod{}, track)
+	if want := maxRotation * math.Tanh(math.Tanh(float64(track[1].center.y)/nnDistanceScale)); math.Abs(got.rotation-want) > 1e-12 || got.thrust != 75 {
+		t.Errorf("act gives %+v, want rotation %v and thrust 75", got, want)
+	}
+}
+
+func TestPolicyNetSteersForTheCheckpoint(t *testing.T) {
+	p := simPod{angle: AngleDegrees(0), mass: 1, nextCheckPointId: 1}
+	ahead := policyNet.act(p, true, [2]simPod{}, networkTrack(0))
+	if math.Abs(ahead.rotation) > 1 || ahead.thrust < 50 {
+		t.Errorf("with the checkpoint ahead it plays %+v", ahead)
+	}
+	right := policyNet.act(p, true, [2]simPod{}, networkTrack(60))
+	left := policyNet.act(p, true, [2]simPod{}, networkTrack(-60))
+	if right.rotation <= 0 || left.rotation >= 0 || math.Abs(right.rotation+left.rotation) > 1e-9 {
+		t.Errorf("turning %v for a checkpoint on the right and %v on the left", right.rotation, left.rotation)
+	}
+	if behind := policyNet.act(p, true, [2]simPod{}, networkTrack(180)); behind.thrust >= ahead.thrust {
+		t.Errorf("thrust %d with the checkpoint behind, %d ahead", behind.thrust, ahead.thrust)
+	}
+}
+
+func TestDecodeNetwork(t *testing.T) {
+	weights := make([]float64, nnWeights)
+	weights[0], weights[nnWeights-1] = 1.5, -0.25
+	n, err := decodeNetwork(encodeNetwork(weights))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.weights[0] != 1.5 || n.weights[nnWeights-1] != -0.25 {
+		t.Errorf("decoded %v and %v, want 1.5 and -0.25", n.weights[0], n.weights[nnWeights-1])
+	}
+	if _, err := decodeNetwork(encodeNetwork(weights[1:])); err == nil || !strings.Contains(err.Error(), "weights") {
+		t.Errorf("a weight short decodes with %v", err)
+	}
+	if _, err := decodeNetwork("not base64!"); err == nil {
+		t.Error("decoded a literal that isn't base64")
+	}
+}
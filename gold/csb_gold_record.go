@@ -0,0 +1,78 @@
+package main
+
+// The record command plays a headless game of the heuristics by a league's
+// rules and writes the input side 0 was sent, as a transcript to feed a bot
+// with:
+//
+//	go run ./gold record -seed 3 > gold/testdata/seed3.in
+//	go run ./gold record -seed 3 -league bronze > bronze/testdata/seed3.in
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+)
+
+func init() {
+	localCommands["record"] = record
+}
+
+// writeGoldHeader writes what the gold bot reads at the start of a game.
+func writeGoldHeader(w io.Writer, track map[int]*checkpoint, laps int) {
+	fmt.Fprintln(w, laps)
+	fmt.Fprintln(w, len(track))
+	for id := 0; id < len(track); id++ {
+		fmt.Fprintln(w, track[id].center.x, track[id].center.y)
+	}
+}
+
+// writeGoldTurn writes what the gold bot reads each turn.
+func writeGoldTurn(w io.Writer, state gameState) {
+	for _, g := range append(state.players[:], state.opponents[:]...) {
+		fmt.Fprintln(w, g.x, g.y, g.vx, g.vy, g.angle, g.nextCheckPointId)
+	}
+}
+
+// writeBronzeTurn writes what the bronze bot reads each turn, seeing only
+// the first pod of each side.
+func writeBronzeTurn(w io.Writer, state gameState, track map[int]*checkpoint) {
+	in := singlePodInput(state.players[0], state.opponents[0], track)
+	fmt.Fprintln(w, in.x, in.y, in.nextCheckpointX, in.nextCheckpointY, in.nextCheckpointDist, in.nextCheckpointAngle)
+	fmt.Fprintln(w, in.opponentX, in.opponentY)
+}
+
+func record(args []string) {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "seed of the track")
+	league := flags.String("league", leagueGold, "league whose rules the game is played by: wood, bronze, silver or gold")
+	flags.Parse(args)
+
+	rules, ok := rulesOf[*league]
+	if !ok {
+		fmt.Println("no league", *league)
+		os.Exit(1)
+	}
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	track := randomTrack(rand.New(rand.NewSource(*seed)))
+	if rules.fullInput {
+		writeGoldHeader(out, track, gameLaps)
+	}
+	heuristics := strategyTeam(strategyHeuristic, nil)
+	playLeagueGame(track, gameLaps, [2]team{heuristics, heuristics}, rules, 0, func(turn, side int, state gameState, seen map[int]*checkpoint, cmds [2]command) {
+		if side != 0 {
+			return
+		}
+		if rules.fullInput {
+			writeGoldTurn(out, state)
+		} else {
+			writeBronzeTurn(out, state, seen)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func shieldTrack() map[int]*checkpoint {
+	track := map[int]*checkpoint{
+		0: {center: point{1000, 1000}, longDistanceAimpoint: point{1000, 1000}},
+		1: {center: point{15000, 8000}, longDistanceAimpoint: point{15000, 8000}},
+	}
+	calculateAimpoints(track)
+	return track
+}
+
+func TestShieldLocksThrust(t *testing.T) {
+	track := shieldTrack()
+	p := simPod{x: 5000, y: 5000, angle: AngleDegrees(0), mass: 1, nextCheckPointId: 1}
+	full := command{x: 9000, y: 5000, thrust: 100}
+	for turn := 0; turn <= shieldTurns; turn++ {
+		cmd := full
+		if turn == 0 {
+			cmd = command{x: 9000, y: 5000, shield: true}
+		}
+		p.apply(cmd)
+		if p.mass != shieldMass || p.vx != 0 {
+			t.Fatalf("turn %d after the SHIELD: mass %v, speed %v", turn, p.mass, p.vx)
+		}
+		p.move(1, track)
+		p.endTurn()
+	}
+	p.apply(full)
+	if p.mass != 1 || p.vx != 100 {
+		t.Errorf("shield over: mass %v, speed %v", p.mass, p.vx)
+	}
+}
+
+func TestShieldCountsDown(t *testing.T) {
+	track := shieldTrack()
+	g := gamer{x: 5000, y: 5000, nextCheckPointId: 1, currentlap: 1, shield: shieldTurns + 1}
+	for want := shieldTurns; want >= 0; want-- {
+		g = observe(g, g.x, g.y, 0, 0, 0, 1, track)
+		if g.shield != want {
+			t.Fatalf("shield %d, want %d", g.shield, want)
+		}
+	}
+	if g = observe(g, g.x, g.y, 0, 0, 0, 1, track); g.shield != 0 {
+		t.Errorf("shield %d once over", g.shield)
+	}
+
+	boost := command{x: 1, y: 2, boost: true}
+	if got := shielded(gamer{shield: 1}, boost); got.boost || got.thrust != 0 {
+		t.Errorf("a shielded pod plays %v", got)
+	}
+	if got := shielded(gamer{}, boost); got != boost {
+		t.Errorf("a pod without shield plays %v", got)
+	}
+	shield := command{x: 1, y: 2, shield: true}
+	if got := shielded(gamer{shield: 2}, shield); got != shield {
+		t.Errorf("shielding again plays %v", got)
+	}
+}
+
+func TestInferShields(t *testing.T) {
+	track := shieldTrack()
+	for _, opponentShields := range []bool{false, true} {
+		prev := initGameState(track, 3)
+		prev.first = false
+		prev.players[0] = gamer{x: 5000, y: 5000, vx: 500, angle: 0, nextCheckPointId: 1, currentlap: 1}
+		prev.players[1] = gamer{x: 1000, y: 8000, nextCheckPointId: 1, currentlap: 1}
+		prev.opponents[0] = gamer{x: 6200, y: 5000, vx: -300, angle: 180, nextCheckPointId: 1, currentlap: 1}
+		prev.opponents[1] = gamer{x: 14000, y: 1000, nextCheckPointId: 1, currentlap: 1}
+		prev.commands, prev.commandsKnown = [2]command{{x: 9000, y: 5000, thrust: 100}, {x: 1000, y: 0, thrust: 0}}, true
+
+		theirs := command{x: 0, y: 5000, thrust: 100, shield: opponentShields}
+		pods := []simPod{newSimPod(prev.players[0]), newSimPod(prev.players[1]), newSimPod(prev.opponents[0]), newSimPod(prev.opponents[1])}
+		if simulateTurn(pods, []command{prev.commands[0], prev.commands[1], theirs, {}}, track)&pairBit(0, 2) == 0 {
+			t.Fatal("the pods missed each other")
+		}
+		state := prev
+		for i := 0; i < 2; i++ {
+			state.players[i] = podGamer(prev.players[i], pods[i], track)
+			state.opponents[i] = podGamer(prev.opponents[i], pods[2+i], track)
+		}
+		inferShields(prev, &state, track)
+		if got := state.opponents[0].shield == shieldTurns; got != opponentShields {
+			t.Errorf("opponent shielding %t taken for shielding %t", opponentShields, got)
+		}
+		if state.opponents[1].shield != 0 {
+			t.Errorf("opponent 1 taken for shielding far from the collision")
+		}
+	}
+}
+
+func TestObserveTurnInfersKnowingOurCommands(t *testing.T) {
+	track := shieldTrack()
+	prev := initGameState(track, 3)
+	prev.first = false
+	prev.players[0] = gamer{x: 5000, y: 5000, vx: 500, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	prev.players[1] = gamer{x: 1000, y: 8000, nextCheckPointId: 1, currentlap: 1}
+	prev.opponents[0] = gamer{x: 6200, y: 5000, vx: -300, angle: 180, nextCheckPointId: 1, currentlap: 1}
+	prev.opponents[1] = gamer{x: 14000, y: 1000, nextCheckPointId: 1, currentlap: 1}
+	ours := [2]command{{x: 9000, y: 5000, thrust: 100}, {x: 1000, y: 0, thrust: 0}}
+	pods := []simPod{newSimPod(prev.players[0]), newSimPod(prev.players[1]), newSimPod(prev.opponents[0]), newSimPod(prev.opponents[1])}
+	simulateTurn(pods, []command{ours[0], ours[1], {x: 0, y: 5000, shield: true}, {}}, track)
+	var sent [4]gamer
+	for i, p := range pods {
+		sent[i] = gamer{x: int(p.x), y: int(p.y), vx: int(p.vx), vy: int(p.vy), angle: int(p.angle.Degrees()), nextCheckPointId: p.nextCheckPointId}
+	}
+
+	// a bot that didn't say what it played can't tell the shield
+	unknown := prev
+	observeTurn(&unknown, sent, track)
+	if unknown.opponents[0].shield != 0 {
+		t.Errorf("shield %d inferred not knowing how our pods were commanded", unknown.opponents[0].shield)
+	}
+	known := prev
+	known.played(ours)
+	observeTurn(&known, sent, track)
+	if known.opponents[0].shield != shieldTurns {
+		t.Errorf("shield %d inferred, want %d", known.opponents[0].shield, shieldTurns)
+	}
+}
+
+func TestShieldLockedOnlyWhereAllowed(t *testing.T) {
+	// a team shielding every turn it can
+	shields := func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+		return [2]command{{x: 8000, y: 4500, shield: true}, {x: 8000, y: 4500, shield: true}}
+	}
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	for _, l := range []string{leagueWood, leagueGold} {
+		g := newHeadlessGame(track, gameLaps, rulesOf[l])
+		locked := false
+		g.play([2]team{shields, shields}, 0, func(turn, side int, state gameState, seen map[int]*checkpoint, cmds [2]command) {
+			if turn > 1 && state.players[0].shield > 0 {
+				locked = true
+			}
+		})
+		if want := rulesOf[l].shield; locked != want {
+			t.Errorf("%s: pods taken for shielded %t, want %t", l, locked, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// The searches need a simulated turn of all four pods to stay well under a
+// microsecond, so that a 60ms turn plays tens of thousands of them, and to
+// never allocate. The heuristics decide a turn in a fraction of a
+// millisecond, most of it writing their debug lines and predicting the
+// team's moves; their vectors work nothing out they are not asked for. On a
+// 3GHz core, what to expect is about:
+//
+//	BenchmarkSmartVectorAdd          1 ns/op
+//	BenchmarkVec2Add                 1 ns/op
+//	BenchmarkFutureCollisionCourse  20 ns/op   0 allocs/op
+//	BenchmarkSimulateTurn          400 ns/op   0 allocs/op
+//	BenchmarkHeuristicTurn       30000 ns/op
+//	BenchmarkRacePlay              800 ns/op   0 allocs/op
+//	BenchmarkCollision              35 ns/op
+
+func benchmarkPods() [4]simPod {
+	return [4]simPod{
+		{x: 5000, y: 5000, vx: 400, vy: 0, angle: AngleDegrees(0), mass: 1, nextCheckPointId: 1},
+		{x: 5900, y: 5100, vx: -300, vy: 50, angle: AngleDegrees(180), mass: 1, nextCheckPointId: 1},
+		{x: 9000, y: 2000, vx: 100, vy: 500, angle: AngleDegrees(90), mass: 1, nextCheckPointId: 2},
+		{x: 2000, y: 7000, vx: 600, vy: -200, angle: AngleDegrees(330), mass: 1, nextCheckPointId: 0},
+	}
+}
+
+func BenchmarkSmartVectorAdd(b *testing.B) {
+	v, w := NewSmartVectorCartesian(3, 4), NewSmartVectorCartesian(-1, 2)
+	for i := 0; i < b.N; i++ {
+		v = v.addVector(w).subtractVector(w)
+	}
+}
+
+func BenchmarkVec2Add(b *testing.B) {
+	v, w := Vec2{3, 4}, Vec2{-1, 2}
+	for i := 0; i < b.N; i++ {
+		v = v.Add(w).Sub(w)
+	}
+	_ = v
+}
+
+func BenchmarkFutureCollisionCourse(b *testing.B) {
+	main := gamer{x: 5000, y: 5000, vx: 400, vy: 0}
+	candidate := gamer{x: 9000, y: 5600, vx: -300, vy: 0}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		futureCollisionCourse(main, candidate, 800, 6)
+	}
+}
+
+func BenchmarkSimulateTurn(b *testing.B) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	start := benchmarkPods()
+	cmds := [4]command{{x: 8000, y: 5000, thrust: 100}, {x: 3000, y: 5000, thrust: 100}, {x: 9000, y: 9000, thrust: 50}, {x: 5000, y: 5000, shield: true}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pods := start
+		simulateTurn(pods[:], cmds[:], track)
+	}
+}
+
+// BenchmarkHeuristicTurn is the heuristics deciding both our pods' moves,
+// out of reach of a duel.
+func BenchmarkHeuristicTurn(b *testing.B) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	start := initGameState(track, 3)
+	start.first = false
+	for i, p := range benchmarkPods() {
+		g := gamer{x: int(p.x), y: int(p.y), vx: int(p.vx), vy: int(p.vy), angle: int(p.angle.Degrees()), nextCheckPointId: p.nextCheckPointId, currentlap: 1}
+		if i < 2 {
+			start.players[i] = g
+		} else {
+			start.opponents[i-2] = g
+		}
+	}
+	deadline := time.Now().Add(time.Hour)
+	for i := 0; i < b.N; i++ {
+		state := start
+		heuristicMove(&state, track, 0, deadline)
+	}
+}
+
+func TestSimulateTurnDoesNotAllocate(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	r := race{pods: benchmarkPods(), runnerId: 0, oppLeaderId: 0}
+	cmds := [4]command{{x: 8000, y: 5000, thrust: 100}, {x: 3000, y: 5000, boost: true}, {x: 9000, y: 9000, thrust: 50}, {x: 5000, y: 5000, shield: true}}
+	if allocs := testing.AllocsPerRun(100, func() {
+		pods := benchmarkPods()
+		simulateTurn(pods[:], cmds[:], track)
+		r.play([2]command{cmds[0], cmds[1]}, track)
+	}); allocs > 0 {
+		t.Errorf("a simulated turn makes %.0f allocations", allocs)
+	}
+}
+
+func BenchmarkRacePlay(b *testing.B) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	start := race{pods: benchmarkPods(), runnerId: 0, oppLeaderId: 0}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := start
+		r.step(0, mctsActions[2], track)
+	}
+}
+
+func BenchmarkCollision(b *testing.B) {
+	start := benchmarkPods()
+	for i := 0; i < b.N; i++ {
+		pods := start
+		if pods[0].collisionTime(&pods[1], 1) >= 0 {
+			bounce(&pods[0], &pods[1])
+		}
+	}
+}
+
+func TestBounceMinimumImpulse(t *testing.T) {
+	// Touching head on at a speed of 10: the elastic push is 5, well under
+	// the referee's minimum impulse, which pushes them apart at 120 more.
+	for _, c := range []struct {
+		massB        float64
+		wantA, wantB float64
+	}{
+		{1, 5 - minImpulse, 5 + minImpulse},
+		{shieldMass, 10 - 100.0/11 - minImpulse, 100.0/11/shieldMass + minImpulse/shieldMass},
+	} {
+		a := simPod{x: 0, y: 0, vx: 10, mass: 1}
+		b := simPod{x: 800, y: 0, mass: c.massB}
+		bounce(&a, &b)
+		if math.Abs(a.vx-c.wantA) > 1e-9 || math.Abs(b.vx-c.wantB) > 1e-9 || a.vy != 0 || b.vy != 0 {
+			t.Errorf("mass %v: speeds %v and %v, want %v and %v", c.massB, a.vx, b.vx, c.wantA, c.wantB)
+		}
+	}
+}
+
+func TestCollisionTime(t *testing.T) {
+	a := simPod{vx: 1000}
+	for _, c := range []struct {
+		b     simPod
+		limit float64
+		want  float64
+	}{
+		{simPod{x: 1600}, 1, 0.8},            // 800 apart, closing at 1000
+		{simPod{x: 2000}, 1, -1},             // touching at 1.2, after the turn
+		{simPod{x: 2000}, 2, 1.2},            // within a longer limit
+		{simPod{x: 2000, y: 900}, 2, -1},     // passing by
+		{simPod{x: 1600, vx: 1200}, 1, -1},   // running away
+		{simPod{x: 700}, 1, 0},               // already overlapping
+		{simPod{x: 1600, vx: -1000}, 1, 0.4}, // head on
+	} {
+		if got := a.collisionTime(&c.b, c.limit); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("with %+v in %v: %v, want %v", c.b, c.limit, got, c.want)
+		}
+	}
+}
+
+func TestCoordinateTeamSwervesTheBlocker(t *testing.T) {
+	track := map[int]*checkpoint{
+		0: {center: point{1000, 5000}, longDistanceAimpoint: point{1000, 5000}},
+		1: {center: point{12000, 5000}, longDistanceAimpoint: point{12000, 5000}},
+	}
+	calculateAimpoints(track)
+	state := initGameState(track, 3)
+	state.players[0] = gamer{x: 5000, y: 5000, vx: 600, angle: 0, nextCheckPointId: 1, currentlap: 1}
+	state.players[1] = gamer{x: 7200, y: 3900, vy: 300, angle: 90, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[0] = gamer{x: 2000, y: 1000, nextCheckPointId: 1, currentlap: 1}
+	state.opponents[1] = gamer{x: 2000, y: 9000, nextCheckPointId: 1, currentlap: 1}
+	// the blocker crosses the runner's path full thrust
+	cmds := [2]command{{x: 12000, y: 5000, thrust: 100}, {x: 7200, y: 9000, thrust: 100}}
+	_, bumped := predictTeam(state, track, 0, cmds, cmds)
+	if !bumped {
+		t.Fatal("the pods don't meet, nothing to coordinate")
+	}
+	got := coordinateTeam(state, track, 0, cmds)
+	if got[0] != cmds[0] {
+		t.Errorf("the runner's move changed to %v", got[0])
+	}
+	if got[1] == cmds[1] {
+		t.Errorf("the blocker still goes %v into the runner", got[1])
+	}
+	before, _ := predictTeam(state, track, 0, cmds, cmds)
+	if after, _ := predictTeam(state, track, 0, got, cmds); after <= before {
+		t.Errorf("the blocker's %v does no better than %v", got[1], cmds[1])
+	}
+}
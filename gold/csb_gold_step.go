@@ -0,0 +1,300 @@
+package main
+
+// The step command steps through a game, a transcript of the bot's input
+// like the ones in testdata or imported from a replay, turn by turn:
+//
+//	go run ./gold step -game gold/testdata/lost1.in
+//
+// Each turn shows the bot's state, the four pods, the commands our pods
+// played, from the .golden file next to the game when there is one, and the
+// commands the opponent's pods look to have played. A pod's move can be
+// decided again with the bot's whole trace, and with parameters changed:
+//
+//	r 1 leader=true vx=250
+//
+// decides pod 1's move again as the runner, going 250 to the right.
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	localCommands["step"] = step
+}
+
+// stepTurn is a turn of the game as the bot saw it.
+type stepTurn struct {
+	state  gameState // before the bot played the turn
+	track  map[int]*checkpoint
+	played []string // the commands of our pods in the game, if known
+	now    [2]command
+	modes  [2]string // how the heuristics moved our pods this turn
+}
+
+// stepper replays a game turn by turn.
+type stepper struct {
+	turns  []stepTurn
+	pods   int      // pods we command
+	turn   int      // the turn shown, from 0
+	trace  *os.File // where the bot's trace goes when deciding a move again
+	budget time.Duration
+}
+
+// newStepper plays the bot through the game, each turn getting budget.
+// played are the commands our pods played in it, possibly none.
+func newStepper(game io.Reader, played []string, budget time.Duration) (*stepper, error) {
+	read, state, pods, err := newTurnReader(game)
+	if err != nil {
+		return nil, err
+	}
+	s := &stepper{pods: pods, budget: budget}
+	for {
+		track, err := read(&state)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("turn %d: %v", len(s.turns)+1, err)
+		}
+		turn := stepTurn{state: state, track: track}
+		if len(played) >= pods {
+			turn.played, played = played[:pods], played[pods:]
+		}
+		turn.now = playTurn(&state, track, time.Now().Add(budget))
+		turn.modes = state.modes
+		s.turns = append(s.turns, turn)
+		// the commands played in the game, when known, tell the next turn
+		// how our pods moved
+		cmds := turn.now
+		for i := range turn.played {
+			if cmd, err := parseCommand(turn.played[i]); err == nil {
+				cmds[i] = cmd
+			}
+		}
+		for i := range cmds {
+			cmds[i] = cmds[i].allowed(rulesOf[league])
+		}
+		state.played(cmds)
+	}
+	if len(s.turns) == 0 {
+		return nil, fmt.Errorf("no turns in the game")
+	}
+	return s, nil
+}
+
+// show writes the turn.
+func (s *stepper) show(w io.Writer) {
+	turn := s.turns[s.turn]
+	state := turn.state
+	fmt.Fprintf(w, "turn %d of %d\n", s.turn+1, len(s.turns))
+	fmt.Fprintf(w, "  laps %d, checkpoints %d, first %t, usedboost %t, lastlap %t, modes this turn %q\n",
+		state.numlaps, state.numcheckpoints, state.first, state.usedboost, state.lastlap, turn.modes)
+	for i, g := range state.players {
+		if i >= s.pods {
+			break
+		}
+		played := "?"
+		if turn.played != nil {
+			played = turn.played[i]
+		}
+		fmt.Fprintf(w, "  pod %d      %+v\n             played %-20s now %v\n", i, g, played, turn.now[i])
+	}
+	for i, g := range state.opponents {
+		if i >= s.pods {
+			break
+		}
+		fmt.Fprintf(w, "  opponent %d %+v\n             played %s\n", i, g, s.opponentCommand(i))
+	}
+}
+
+// opponentCommand is what the opponent's pod i looks to have played this
+// turn.
+func (s *stepper) opponentCommand(i int) string {
+	cmd, ok := s.opponentMove(s.turn, i)
+	if !ok {
+		return "?"
+	}
+	return cmd.String() + " (estimated)"
+}
+
+// opponentMove is what the opponent's pod i looks to have played on turn,
+// from 0, worked back from where it got to by the next turn: its speeds
+// are exact, and its positions only rounded. A collision makes it nonsense.
+// ok is false on the last turn, and in the leagues that don't send the
+// opponent's speed.
+func (s *stepper) opponentMove(turn, i int) (cmd command, ok bool) {
+	if turn+1 >= len(s.turns) || s.pods < 2 {
+		return command{}, false
+	}
+	before, after := s.turns[turn].state.opponents[i], s.turns[turn+1].state.opponents[i]
+	facing := AngleDegrees(float64(after.angle))
+	sin, cos := math.Sincos(facing.Radians())
+	ax := float64(after.x - before.x - before.vx)
+	ay := float64(after.y - before.y - before.vy)
+	thrust := int(math.Round(ax*cos + ay*sin))
+	cmd = command{x: before.x + int(1000*cos), y: before.y + int(1000*sin)}
+	if thrust > (100+boostThrust)/2 {
+		cmd.boost = true
+	} else if thrust < 0 {
+		cmd.thrust = 0
+	} else if thrust > 100 {
+		cmd.thrust = 100
+	} else {
+		cmd.thrust = thrust
+	}
+	return cmd, true
+}
+
+// redecide decides pod's move at this turn again, the bot tracing to
+// s.trace, with the overrides given as key=value: leader, first, usedboost
+// and lastlap for the state, x, y, vx, vy, angle, ncp, lap and shield for
+// the pod.
+func (s *stepper) redecide(w io.Writer, pod int, overrides []string) error {
+	if pod < 0 || pod >= s.pods {
+		return fmt.Errorf("no pod %d", pod)
+	}
+	turn := s.turns[s.turn]
+	state := turn.state
+	isLeader := determineLeader(state.players) == pod
+	g := &state.players[pod]
+	for _, o := range overrides {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%q isn't key=value", o)
+		}
+		key, value := kv[0], kv[1]
+		switch key {
+		case "leader", "first", "usedboost", "lastlap":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			switch key {
+			case "leader":
+				isLeader = b
+			case "first":
+				state.first = b
+			case "usedboost":
+				state.usedboost = b
+			case "lastlap":
+				state.lastlap = b
+			}
+		default:
+			fields := map[string]*int{"x": &g.x, "y": &g.y, "vx": &g.vx, "vy": &g.vy, "angle": &g.angle, "ncp": &g.nextCheckPointId, "lap": &g.currentlap, "shield": &g.shield}
+			field, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("cannot change %q", key)
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			*field = n
+		}
+	}
+	if turn.track[g.nextCheckPointId] == nil {
+		return fmt.Errorf("checkpoint %d isn't on the track", g.nextCheckPointId)
+	}
+	*g = observe(*g, g.x, g.y, g.vx, g.vy, g.angle, g.nextCheckPointId, turn.track)
+
+	stderr := os.Stderr
+	os.Stderr = s.trace
+	cmd, mode := movePlayer(pod, isLeader, state, turn.track, time.Now().Add(s.budget))
+	os.Stderr = stderr
+	fmt.Fprintf(w, "pod %d moves %v in mode %s\n", pod, cmd, mode)
+	return nil
+}
+
+const stepHelp = `n, or nothing   next turn
+p               previous turn
+g TURN          go to turn TURN
+r POD [K=V...]  decide pod POD's move again, tracing, with K set to V:
+                leader, first, usedboost, lastlap, x, y, vx, vy, angle, ncp, lap,
+                shield
+q               quit
+`
+
+// exec runs a line the user typed, and returns whether to quit.
+func (s *stepper) exec(w io.Writer, line string) bool {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		words = []string{"n"}
+	}
+	switch words[0] {
+	case "q":
+		return true
+	case "n", "p", "g":
+		to := s.turn + 1
+		if words[0] == "p" {
+			to = s.turn - 1
+		} else if words[0] == "g" {
+			n := 0
+			if len(words) > 1 {
+				n, _ = strconv.Atoi(words[1])
+			}
+			to = n - 1
+		}
+		if to < 0 || to >= len(s.turns) {
+			fmt.Fprintf(w, "no turn %d, there are %d\n", to+1, len(s.turns))
+			return false
+		}
+		s.turn = to
+		s.show(w)
+	case "r":
+		pod, overrides := -1, []string(nil)
+		if len(words) > 1 {
+			pod, _ = strconv.Atoi(words[1])
+			overrides = words[2:]
+		}
+		if err := s.redecide(w, pod, overrides); err != nil {
+			fmt.Fprintln(w, err)
+		}
+	default:
+		fmt.Fprint(w, stepHelp)
+	}
+	return false
+}
+
+func step(args []string) {
+	flags := flag.NewFlagSet("step", flag.ExitOnError)
+	game := flags.String("game", "gold/testdata/seed1.in", "transcript of the game")
+	turn := flags.Int("turn", 1, "turn to start at")
+	budget := flags.Duration("budget", 0, "time the bot gets per turn")
+	flags.Parse(args)
+
+	trace := os.Stdout
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	f, err := os.Open(*game)
+	if err != nil {
+		fmt.Println("cannot read the game:", err)
+		os.Exit(1)
+	}
+	var played []string
+	if golden, err := ioutil.ReadFile(strings.TrimSuffix(*game, ".in") + ".golden"); err == nil {
+		played = splitLines(string(golden))
+	}
+	s, err := newStepper(f, played, *budget)
+	f.Close()
+	if err != nil {
+		fmt.Println("cannot replay the game:", err)
+		os.Exit(1)
+	}
+	s.trace = trace
+
+	s.exec(os.Stdout, fmt.Sprintf("g %d", *turn))
+	lines := bufio.NewScanner(os.Stdin)
+	for fmt.Print("> "); lines.Scan(); fmt.Print("> ") {
+		if s.exec(os.Stdout, lines.Text()) {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestStepper(t *testing.T, game string) *stepper {
+	t.Helper()
+	in, err := os.Open(filepath.Join("testdata", game+".in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", game+".golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newStepper(in, splitLines(string(golden)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.trace = os.Stderr
+	return s
+}
+
+func TestStepper(t *testing.T) {
+	s := newTestStepper(t, "seed1")
+	for i, turn := range s.turns {
+		for pod := range turn.played {
+			if now := turn.now[pod].String(); now != turn.played[pod] {
+				t.Fatalf("turn %d: pod %d now plays %q, played %q", i+1, pod, now, turn.played[pod])
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	for _, line := range []string{"g 30", "", "p", "r 1", "r 1 leader=true vx=250 usedboost=true", "r 1 speed=3", "r 2", "g 9999", "r", "help"} {
+		if s.exec(&out, line) {
+			t.Fatalf("%q quit", line)
+		}
+	}
+	if !s.exec(&out, "q") {
+		t.Error("q didn't quit")
+	}
+	if s.turn != 29 {
+		t.Errorf("at turn %d after going to 30, on and back", s.turn+1)
+	}
+	for _, want := range []string{"turn 31 of", "turn 30 of", "opponent 1 {", "(estimated)", "pod 1 moves", `cannot change "speed"`, "no pod 2", "no turn 9999", "decide pod POD's move again"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("no %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStepperSinglePod(t *testing.T) {
+	s := newTestStepper(t, "bronze1")
+	var out bytes.Buffer
+	s.exec(&out, "g 2")
+	if strings.Contains(out.String(), "pod 1") || strings.Contains(out.String(), "opponent 1") {
+		t.Errorf("shows pods the single pod protocol hasn't:\n%s", out.String())
+	}
+	s.exec(&out, "r 0 x=5000")
+	if !strings.Contains(out.String(), "pod 0 moves") {
+		t.Errorf("pod 0 wasn't decided again:\n%s", out.String())
+	}
+}
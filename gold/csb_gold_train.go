@@ -0,0 +1,219 @@
+package main
+
+// The trainer evolves the network's weights by self-play, with the cross
+// entropy method: sample weights around a mean, play headless games with
+// them against the heuristic bot, and move the mean to the best samples.
+//
+//	go run ./gold train -generations 200 -checkpoint train.json -out weights.go.txt
+//
+// Paste the line written to -out over nnWeightsLiteral to submit the result.
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	localCommands["train"] = train
+}
+
+// trainerState is what the checkpoint file keeps to resume training.
+type trainerState struct {
+	Generation  int
+	Seed        int64
+	Mean        []float64
+	Deviation   []float64
+	Evaluations int
+	BestScore   float64 // of the evaluations so far
+}
+
+// encodeNetwork is the literal decodeNetwork reads the weights back from.
+func encodeNetwork(weights []float64) string {
+	raw := make([]byte, 2*len(weights))
+	for i, w := range weights {
+		v := math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(w*1024)))
+		binary.LittleEndian.PutUint16(raw[2*i:], uint16(int16(v)))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// generationRand is the source of the tracks and samples of a generation,
+// its own so that a resumed training goes on where it stopped. Generation 0
+// is the evaluations'.
+func generationRand(seed int64, generation int) *rand.Rand {
+	return rand.New(rand.NewSource(seed<<20 + int64(generation)))
+}
+
+// match is one game of the trainer: a track and whether the network plays
+// side 0 or side 1 on it.
+type match struct {
+	track map[int]*checkpoint
+	side  int
+}
+
+func randomMatches(rnd *rand.Rand, count int) []match {
+	matches := make([]match, count)
+	for i := range matches {
+		matches[i] = match{track: randomTrack(rnd), side: i % 2}
+	}
+	return matches
+}
+
+// fitness plays the matches with the weights against the heuristic bot: the
+// lead of our best pod over theirs, in checkpoints, plus one for each win.
+// It also returns how many games the network won.
+func fitness(weights []float64, matches []match, budget time.Duration) (float64, int) {
+	net := &network{}
+	copy(net.weights[:], weights)
+	total, wins := 0.0, 0
+	for _, m := range matches {
+		var sides [2]team
+		sides[m.side] = strategyTeam(strategyNeural, net)
+		sides[1-m.side] = strategyTeam(strategyHeuristic, nil)
+		result := playGame(m.track, gameLaps, sides, budget, nil)
+		total += (result.progress[m.side] - result.progress[1-m.side]) / checkpointProgress
+		if result.winner == m.side {
+			total++
+			wins++
+		}
+	}
+	return total / float64(len(matches)), wins
+}
+
+// scoreAll computes the fitness of every sample on the workers.
+func scoreAll(samples [][]float64, matches []match, budget time.Duration, workers int) []float64 {
+	scores := make([]float64, len(samples))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				scores[i], _ = fitness(samples[i], matches, budget)
+			}
+		}()
+	}
+	for i := range samples {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return scores
+}
+
+func loadTrainerState(path string) (trainerState, error) {
+	var ts trainerState
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ts, err
+	}
+	err = json.Unmarshal(data, &ts)
+	if err == nil && (len(ts.Mean) != nnWeights || len(ts.Deviation) != nnWeights) {
+		err = fmt.Errorf("%s has %d weights, want %d", path, len(ts.Mean), nnWeights)
+	}
+	return ts, err
+}
+
+func saveTrainerState(path string, ts trainerState) error {
+	data, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func train(args []string) {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	generations := flags.Int("generations", 100, "generations to train for")
+	population := flags.Int("population", 32, "weight samples per generation")
+	elite := flags.Int("elite", 8, "best samples the next mean is taken from")
+	games := flags.Int("games", 4, "games each sample plays per generation")
+	deviation := flags.Float64("deviation", 0.5, "initial standard deviation of the samples")
+	evalEvery := flags.Int("eval", 10, "generations between evaluations of the mean")
+	evalGames := flags.Int("evalgames", 40, "games of an evaluation")
+	budget := flags.Duration("budget", 0, "time per turn given to the bots")
+	seed := flags.Int64("seed", 1, "seed of the tracks and samples, the checkpoint's when resuming")
+	workers := flags.Int("workers", runtime.NumCPU(), "games played in parallel")
+	checkpointPath := flags.String("checkpoint", "train.json", "file the training state is saved to")
+	resume := flags.Bool("resume", false, "resume from the checkpoint file")
+	out := flags.String("out", "weights.go.txt", "file the weights literal of the mean is written to")
+	flags.Parse(args)
+
+	// The bots explain each of their moves on stderr; nobody reads it here.
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	ts := trainerState{Seed: *seed, Mean: append([]float64(nil), policyNet.weights[:]...)}
+	ts.Deviation = make([]float64, nnWeights)
+	for i := range ts.Deviation {
+		ts.Deviation[i] = *deviation
+	}
+	if *resume {
+		var err error
+		if ts, err = loadTrainerState(*checkpointPath); err != nil {
+			fmt.Println("cannot resume:", err)
+			os.Exit(1)
+		}
+	}
+	evalMatches := randomMatches(generationRand(ts.Seed, 0), *evalGames)
+
+	for ts.Generation < *generations {
+		ts.Generation++
+		rnd := generationRand(ts.Seed, ts.Generation)
+		matches := randomMatches(rnd, *games)
+		samples := make([][]float64, *population)
+		for s := range samples {
+			samples[s] = make([]float64, nnWeights)
+			for i := range samples[s] {
+				samples[s][i] = ts.Mean[i] + ts.Deviation[i]*rnd.NormFloat64()
+			}
+		}
+		scores := scoreAll(samples, matches, *budget, *workers)
+		order := make([]int, len(samples))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+		for i := range ts.Mean {
+			mean := 0.0
+			for _, s := range order[:*elite] {
+				mean += samples[s][i]
+			}
+			mean /= float64(*elite)
+			variance := 0.0
+			for _, s := range order[:*elite] {
+				variance += (samples[s][i] - mean) * (samples[s][i] - mean)
+			}
+			// a little noise keeps the search from collapsing too early
+			ts.Mean[i], ts.Deviation[i] = mean, math.Sqrt(variance/float64(*elite))+0.01
+		}
+		fmt.Printf("generation %d: best %.3f, elite worst %.3f\n", ts.Generation, scores[order[0]], scores[order[*elite-1]])
+
+		if ts.Generation%*evalEvery == 0 || ts.Generation == *generations {
+			score, wins := fitness(ts.Mean, evalMatches, *budget)
+			fmt.Printf("evaluation: %.3f, won %d of %d against the heuristics\n", score, wins, len(evalMatches))
+			if ts.Evaluations == 0 || score > ts.BestScore {
+				ts.BestScore = score
+				literal := fmt.Sprintf("var nnWeightsLiteral = %q\n", encodeNetwork(ts.Mean))
+				if err := ioutil.WriteFile(*out, []byte(literal), 0644); err != nil {
+					fmt.Println("cannot export the weights:", err)
+				}
+			}
+			ts.Evaluations++
+		}
+		if err := saveTrainerState(*checkpointPath, ts); err != nil {
+			fmt.Println("cannot save the checkpoint:", err)
+		}
+	}
+}
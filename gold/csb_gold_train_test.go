@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrainerStateResumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "train")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "train.json")
+	ts := trainerState{Generation: 7, Seed: 42, Mean: make([]float64, nnWeights), Deviation: make([]float64, nnWeights), Evaluations: 1, BestScore: 0.5}
+	ts.Mean[3], ts.Deviation[3] = 1.25, 0.125
+	if err := saveTrainerState(path, ts); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadTrainerState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, ts) {
+		t.Errorf("loaded %+v, saved %+v", loaded, ts)
+	}
+
+	// the resumed generation draws what it would have without stopping, not
+	// the first generation's again
+	next := generationRand(loaded.Seed, loaded.Generation+1).Int63()
+	if again := generationRand(ts.Seed, ts.Generation+1).Int63(); next != again {
+		t.Errorf("generation 8 draws %d, then %d", next, again)
+	}
+	if first := generationRand(ts.Seed, 1).Int63(); next == first {
+		t.Error("generation 8 draws what the first generation did")
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+// The whatif command forks a game of the gold league at a turn, plays other
+// commands for our pods, and races on headless to see what they would have
+// changed:
+//
+//	go run ./gold whatif -game gold/testdata/lost1.in -at 80 -set "87 0 SHIELD"
+//
+// plays the game on from turn 80 with our pod 0 shielding on turn 87, a
+// full command like "87 0 8000 4500 100" also giving the target. Our bot
+// plays the other turns, and the opponent plays the commands it looks to
+// have played in the game, or a strategy of ours with -opponent. The lead
+// of the fork, in checkpoints, is compared with the game's and with the
+// game played on from the same turn without the changes, which shows how
+// far the headless race drifts from the game on its own. The recorded
+// commands don't steer: once a collision goes another way than in the game
+// the opponent drifts off its course, so for more than a few dozen turns a
+// strategy plays it better.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	localCommands["whatif"] = whatIf
+}
+
+// opponentRecorded is the opponent of a fork playing what it played in the
+// game.
+const opponentRecorded = "recorded"
+
+// substitution is a command to play for our pod instead of the bot's on a
+// turn, from 1. Without a target the bot's is kept.
+type substitution struct {
+	turn, pod  int
+	cmd        command
+	keepTarget bool
+}
+
+// parseSubstitution reads "TURN POD [X Y] THRUST|BOOST|SHIELD".
+func parseSubstitution(s string) (substitution, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 && len(fields) != 5 {
+		return substitution{}, fmt.Errorf("%q isn't TURN POD [X Y] THRUST|BOOST|SHIELD", s)
+	}
+	var numbers []int
+	for _, f := range fields[:len(fields)-1] {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return substitution{}, fmt.Errorf("%q: %v", s, err)
+		}
+		numbers = append(numbers, n)
+	}
+	sub := substitution{turn: numbers[0], pod: numbers[1], keepTarget: len(numbers) == 2}
+	if sub.pod < 0 || sub.pod > 1 {
+		return sub, fmt.Errorf("%q: no pod %d", s, sub.pod)
+	}
+	if !sub.keepTarget {
+		sub.cmd.x, sub.cmd.y = numbers[2], numbers[3]
+	}
+	if action := fields[len(fields)-1]; !setThrust(&sub.cmd, action) {
+		return sub, fmt.Errorf("%q: thrust %q", s, action)
+	}
+	return sub, nil
+}
+
+// substituted is t playing the substitutions on their turns, the first turn
+// it plays being turn+1.
+func substituted(t team, subs []substitution, turn int) team {
+	return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+		turn++
+		cmds := t(state, track, deadline)
+		for _, sub := range subs {
+			if sub.turn != turn {
+				continue
+			}
+			cmd := sub.cmd
+			if sub.keepTarget {
+				cmd.x, cmd.y = cmds[sub.pod].x, cmds[sub.pod].y
+			}
+			cmds[sub.pod] = cmd
+		}
+		return cmds
+	}
+}
+
+// recordedOpponent plays what the opponent looks to have played in the game
+// from turn on, and strategy t once the game is over. The targets are moved
+// along with the pods, for them to face the same way as in the game.
+func (s *stepper) recordedOpponent(t team, turn int) team {
+	return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+		turn++
+		cmds := t(state, track, deadline)
+		for i := range cmds {
+			if cmd, ok := s.opponentMove(turn-1, i); ok {
+				was := s.turns[turn-1].state.opponents[i]
+				cmd.x += state.players[i].x - was.x
+				cmd.y += state.players[i].y - was.y
+				cmds[i] = cmd
+			}
+		}
+		return cmds
+	}
+}
+
+// passedCheckpoints is how many checkpoints g passed since the start.
+func passedCheckpoints(g gamer, checkpoints int) int {
+	return (g.currentlap-1)*checkpoints + g.nextCheckPointId - 1
+}
+
+// racePod is g as the referee has it, for a headless game.
+func racePod(g gamer, checkpoints int) simPod {
+	p := newSimPod(g)
+	p.passed = passedCheckpoints(g, checkpoints)
+	return p
+}
+
+// lead is how far, in checkpoints, our best pod is ahead of the opponent's.
+func lead(progress [2]float64) float64 {
+	return (progress[0] - progress[1]) / checkpointProgress
+}
+
+// originalLead is our lead at the start of turn, from 0, in the game.
+func (s *stepper) originalLead(turn int) float64 {
+	state, track := s.turns[turn].state, s.turns[turn].track
+	var progress [2]float64
+	for side, pods := range [2][2]gamer{state.players, state.opponents} {
+		for i, g := range pods {
+			p := racePod(g, len(track)).progress(track)
+			if i == 0 || p > progress[side] {
+				progress[side] = p
+			}
+		}
+	}
+	return lead(progress)
+}
+
+// fork is the headless game of the game at the start of turn at, from 0,
+// our pods being side 0.
+func (s *stepper) fork(at int) (*headlessGame, error) {
+	if s.pods < 2 {
+		return nil, fmt.Errorf("only games of the gold league can be forked, the others don't say where all the pods are")
+	}
+	if at < 0 || at >= len(s.turns) {
+		return nil, fmt.Errorf("no turn %d, there are %d", at+1, len(s.turns))
+	}
+	turn := s.turns[at]
+	state, track := turn.state, turn.track
+	g := &headlessGame{
+		track:  track,
+		laps:   state.numlaps,
+		rules:  rulesOf[leagueGold],
+		result: gameResult{winner: -1, turns: at},
+	}
+	for _, pod := range append(state.players[:], state.opponents[:]...) {
+		g.pods = append(g.pods, racePod(pod, len(track)))
+	}
+	opponents := initGameState(track, state.numlaps)
+	opponents.first = state.first
+	opponents.players, opponents.opponents = state.opponents, state.players
+	g.states = [2]gameState{state, opponents}
+	g.boosted, g.idle = s.boostedBefore(at), s.idleAt(at)
+	return g, nil
+}
+
+// boostedBefore is which pods boosted before turn at, from 0, for the fork
+// not to let them boost again: ours by the commands they played, or by the
+// bot's usedboost when they aren't known, the opponent's by their estimated
+// moves.
+func (s *stepper) boostedBefore(at int) [4]bool {
+	var boosted [4]bool
+	for turn := 0; turn < at; turn++ {
+		for i := 0; i < 2; i++ {
+			if played := s.turns[turn].played; played != nil {
+				if cmd, err := parseCommand(played[i]); err == nil && cmd.boost {
+					boosted[i] = true
+				}
+			}
+			if cmd, ok := s.opponentMove(turn, i); ok && cmd.boost {
+				boosted[2+i] = true
+			}
+		}
+	}
+	if s.turns[at].state.usedboost && s.turns[0].played == nil {
+		boosted[0], boosted[1] = true, true
+	}
+	return boosted
+}
+
+// idleAt is how many turns each side went without passing a checkpoint by
+// the start of turn at, from 0.
+func (s *stepper) idleAt(at int) [2]int {
+	var idle [2]int
+	for side := range idle {
+		idle[side] = at
+		for turn := at; turn > 0; turn-- {
+			before, after := s.turns[turn-1].state, s.turns[turn].state
+			pods, was := after.players, before.players
+			if side == 1 {
+				pods, was = after.opponents, before.opponents
+			}
+			checkpoints := len(s.turns[turn].track)
+			if passedCheckpoints(pods[0], checkpoints) > passedCheckpoints(was[0], checkpoints) ||
+				passedCheckpoints(pods[1], checkpoints) > passedCheckpoints(was[1], checkpoints) {
+				idle[side] = at - turn
+				break
+			}
+		}
+	}
+	return idle
+}
+
+// branch is how a fork of the game went.
+type branch struct {
+	leads  []float64 // our lead at the start of each turn from the fork
+	result gameResult
+}
+
+// playFork plays the game on from turn at, from 0, ours playing
+// substitutions, each side getting budget per turn.
+func (s *stepper) playFork(at int, ours, theirs team, subs []substitution, budget time.Duration) (branch, error) {
+	g, err := s.fork(at)
+	if err != nil {
+		return branch{}, err
+	}
+	var b branch
+	sides := [2]team{substituted(ours, subs, at), theirs}
+	b.result = g.play(sides, budget, func(turn, side int, state gameState, track map[int]*checkpoint, cmds [2]command) {
+		if side == 0 {
+			b.leads = append(b.leads, lead(g.progress()))
+		}
+	})
+	return b, nil
+}
+
+// writeWhatIf compares the game from turn at, from 0, with the fork played
+// on the same way and the fork with the substitutions, every few turns.
+func (s *stepper) writeWhatIf(w io.Writer, at int, replayed, whatIf branch, every int) {
+	fmt.Fprintf(w, "%6s %10s %10s %10s\n", "turn", "game", "replayed", "what if")
+	column := func(leads []float64, i int) string {
+		if i >= len(leads) {
+			return "-"
+		}
+		return strconv.FormatFloat(leads[i], 'f', 2, 64)
+	}
+	var original []float64
+	for turn := at; turn < len(s.turns); turn++ {
+		original = append(original, s.originalLead(turn))
+	}
+	turns := len(original)
+	for _, b := range []branch{replayed, whatIf} {
+		if len(b.leads) > turns {
+			turns = len(b.leads)
+		}
+	}
+	for i := 0; i < turns; i++ {
+		if i%every == 0 || i == turns-1 {
+			fmt.Fprintf(w, "%6d %10s %10s %10s\n", at+i+1, column(original, i), column(replayed.leads, i), column(whatIf.leads, i))
+		}
+	}
+	outcome := func(result gameResult) string {
+		if result.winner == 0 {
+			return fmt.Sprintf("won in %d turns", result.turns)
+		}
+		return fmt.Sprintf("lost in %d turns", result.turns)
+	}
+	fmt.Fprintf(w, "game: %d turns, lead %.2f at the last\n", len(s.turns), original[len(original)-1])
+	fmt.Fprintf(w, "replayed: %s, lead %.2f\n", outcome(replayed.result), lead(replayed.result.progress))
+	fmt.Fprintf(w, "what if: %s, lead %.2f, %+.2f on replayed\n", outcome(whatIf.result), lead(whatIf.result.progress),
+		lead(whatIf.result.progress)-lead(replayed.result.progress))
+}
+
+// substitutions is the repeatable -set flag.
+type substitutions []substitution
+
+func (subs *substitutions) String() string {
+	return fmt.Sprint(*subs)
+}
+
+func (subs *substitutions) Set(s string) error {
+	sub, err := parseSubstitution(s)
+	if err == nil {
+		*subs = append(*subs, sub)
+	}
+	return err
+}
+
+func whatIf(args []string) {
+	var subs substitutions
+	flags := flag.NewFlagSet("whatif", flag.ExitOnError)
+	game := flags.String("game", "gold/testdata/seed1.in", "transcript of a game of the gold league")
+	at := flags.Int("at", 1, "turn to fork the game at")
+	flags.Var(&subs, "set", `command to play instead of the bot's, "TURN POD [X Y] THRUST|BOOST|SHIELD"; repeatable`)
+	ours := flags.String("strategy", strategyHeuristic, "strategy our bot plays")
+	opponent := flags.String("opponent", opponentRecorded, "what the opponent plays: recorded, or a strategy of ours")
+	budget := flags.Duration("budget", 0, "time each side gets per turn")
+	every := flags.Int("every", 10, "turns between the lines of the comparison")
+	flags.Parse(args)
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	f, err := os.Open(*game)
+	if err != nil {
+		fmt.Println("cannot read the game:", err)
+		os.Exit(1)
+	}
+	var played []string
+	if golden, err := ioutil.ReadFile(strings.TrimSuffix(*game, ".in") + ".golden"); err == nil {
+		played = splitLines(string(golden))
+	}
+	s, err := newStepper(f, played, *budget)
+	f.Close()
+	if err != nil {
+		fmt.Println("cannot replay the game:", err)
+		os.Exit(1)
+	}
+	for _, sub := range subs {
+		if sub.turn < *at {
+			fmt.Printf("turn %d is before the fork at %d\n", sub.turn, *at)
+			os.Exit(1)
+		}
+	}
+	if *every < 1 {
+		*every = 1
+	}
+
+	// Each branch gets teams of its own, the bots keep state between turns.
+	teams := func() (team, team) {
+		theirs := strategyTeam(*opponent, policyNet)
+		if *opponent == opponentRecorded {
+			theirs = s.recordedOpponent(strategyTeam(strategyHeuristic, nil), *at-1)
+		}
+		return strategyTeam(*ours, policyNet), theirs
+	}
+	us, them := teams()
+	replayed, err := s.playFork(*at-1, us, them, nil, *budget)
+	if err != nil {
+		fmt.Println("cannot fork the game:", err)
+		os.Exit(1)
+	}
+	us, them = teams()
+	changed, _ := s.playFork(*at-1, us, them, subs, *budget)
+	s.writeWhatIf(os.Stdout, *at-1, replayed, changed, *every)
+}
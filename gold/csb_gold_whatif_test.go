@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseSubstitution(t *testing.T) {
+	for s, want := range map[string]substitution{
+		"87 0 SHIELD":          {turn: 87, pod: 0, cmd: command{shield: true}, keepTarget: true},
+		"12 1 BOOST":           {turn: 12, pod: 1, cmd: command{boost: true}, keepTarget: true},
+		"5 1 8000 4500 40":     {turn: 5, pod: 1, cmd: command{x: 8000, y: 4500, thrust: 40}},
+		" 5  0  -10 20 SHIELD": {turn: 5, pod: 0, cmd: command{x: -10, y: 20, shield: true}},
+	} {
+		if got, err := parseSubstitution(s); err != nil || got != want {
+			t.Errorf("%q: got %+v, %v, want %+v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "87 0", "87 2 SHIELD", "87 0 101", "87 0 1 2", "x 0 SHIELD", "87 0 8000 y 100"} {
+		if sub, err := parseSubstitution(s); err == nil {
+			t.Errorf("%q: got %+v", s, sub)
+		}
+	}
+}
+
+func TestWhatIf(t *testing.T) {
+	s := newTestStepper(t, "seed1")
+	at := 79
+	heuristics := strategyTeam(strategyHeuristic, nil)
+	replayed, err := s.playFork(at, heuristics, s.recordedOpponent(heuristics, at), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if original := s.originalLead(at + i); math.Abs(replayed.leads[i]-original) > 0.01 {
+			t.Errorf("turn %d: replayed lead %.3f, %.3f in the game", at+i+1, replayed.leads[i], original)
+		}
+	}
+
+	// Our runner stops for a turn to shield.
+	runner := determineLeader(s.turns[at+2].state.players)
+	subs := []substitution{{turn: at + 3, pod: runner, cmd: command{shield: true}, keepTarget: true}}
+	whatIf, _ := s.playFork(at, heuristics, s.recordedOpponent(heuristics, at), subs, 0)
+	if whatIf.leads[2] != replayed.leads[2] {
+		t.Errorf("what if differs before the substitution: %.3f, replayed %.3f", whatIf.leads[2], replayed.leads[2])
+	}
+	if whatIf.leads[3] >= replayed.leads[3] {
+		t.Errorf("the runner shielded and lost nothing: %.3f, replayed %.3f", whatIf.leads[3], replayed.leads[3])
+	}
+
+	var out strings.Builder
+	s.writeWhatIf(&out, at, replayed, whatIf, 50)
+	for _, want := range []string{"replayed", "    80 ", "what if: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("no %q in:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := newTestStepper(t, "bronze1").fork(10); err == nil {
+		t.Error("forked a game of the single pod protocol")
+	}
+}
+
+func TestForkKeepsBoostsAndIdleTurns(t *testing.T) {
+	s := newTestStepper(t, "seed1")
+	// Our pod 1 boosts on turn 1, and so does the opponent's pod 0.
+	if g, _ := s.fork(0); g.boosted != [4]bool{} {
+		t.Errorf("boosted %v before the game starts", g.boosted)
+	}
+	g, err := s.fork(79)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [4]bool{false, true, true, false}; g.boosted != want {
+		t.Errorf("boosted %v at turn 80, want %v", g.boosted, want)
+	}
+	// without the commands we played, the bot's usedboost tells
+	unplayed := *s
+	unplayed.turns = append([]stepTurn(nil), s.turns...)
+	for i := range unplayed.turns {
+		unplayed.turns[i].played = nil
+	}
+	at := 150
+	if !s.turns[at].state.usedboost {
+		t.Fatalf("the bot hasn't used its boost by turn %d", at+1)
+	}
+	if g, _ := unplayed.fork(at); !g.boosted[0] || !g.boosted[1] {
+		t.Errorf("boosted %v at turn %d, with usedboost", g.boosted, at+1)
+	}
+
+	// each turn a side goes one more without passing a checkpoint, or none
+	prev := [2]int{}
+	for at := 1; at < len(s.turns); at++ {
+		g, _ := s.fork(at)
+		for side, idle := range g.idle {
+			if idle != prev[side]+1 && idle != 0 {
+				t.Fatalf("turn %d: side %d idle %d turns, %d the turn before", at+1, side, idle, prev[side])
+			}
+		}
+		prev = g.idle
+	}
+}
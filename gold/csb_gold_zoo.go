@@ -0,0 +1,245 @@
+package main
+
+// The zoo: reference opponents of different styles to race our strategies
+// against locally, so a change to aggroMove or fullDefenseMode can be
+// checked against more than our own bots.
+//
+//	go run ./gold versus -bots follower,rammer -games 40
+//
+// races the heuristics against each bot of the zoo, and
+//
+//	go run ./gold zoo -bot camper < gold/testdata/seed1.in
+//
+// plays one of them over the game's protocol, like the bot itself.
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	localCommands["zoo"] = zooCommand
+	localCommands["versus"] = versus
+}
+
+// zoo has the reference bots by name. Each call makes a new one, the bots
+// keep state from one turn to the next.
+var zoo = map[string]func() team{
+	// follower goes straight for the checkpoints with both pods.
+	"follower": func() team {
+		return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+			return [2]command{follow(state.players[0], track), follow(state.players[1], track)}
+		}
+	},
+	// rammer races one pod and rams the opponent's runner full thrust with
+	// the other.
+	"rammer": func() team {
+		boosted := false
+		return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+			runnerId := determineLeader(state.players)
+			target := state.opponents[determineLeader(state.opponents)]
+			var cmds [2]command
+			cmds[runnerId] = follow(state.players[runnerId], track)
+			rammer := state.players[1-runnerId]
+			cmds[1-runnerId] = command{x: target.x + 3*target.vx, y: target.y + 3*target.vy, thrust: 100}
+			toTarget := NewSmartVectorCartesian(float64(target.x-rammer.x), float64(target.y-rammer.y))
+			if !boosted && toTarget.length() > 3000 && math.Abs(AngleDegrees(float64(rammer.angle)).Turn(AngleDegrees(toTarget.angleDegrees()))) < 10 {
+				cmds[1-runnerId].boost, boosted = true, true
+			}
+			return cmds
+		}
+	},
+	// camper races one pod and parks the other on the checkpoint after the
+	// one the opponent's runner goes for, to hit it when it comes.
+	"camper": func() team {
+		return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+			runnerId := determineLeader(state.players)
+			target := state.opponents[determineLeader(state.opponents)]
+			var cmds [2]command
+			cmds[runnerId] = follow(state.players[runnerId], track)
+			cmds[1-runnerId] = camp(state.players[1-runnerId], target, track)
+			return cmds
+		}
+	},
+	// shielder races both pods and shields whenever another pod comes near.
+	"shielder": func() team {
+		return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+			var cmds [2]command
+			for i, p := range state.players {
+				cmds[i] = follow(p, track)
+				others := []gamer{state.players[1-i], state.opponents[0], state.opponents[1]}
+				for _, o := range others {
+					if math.Hypot(float64(p.x+p.vx-o.x-o.vx), float64(p.y+p.vy-o.y-o.vy)) < 2*podRadius+100 {
+						cmds[i].shield = true
+					}
+				}
+			}
+			return cmds
+		}
+	},
+	// searcher races both pods, each picking the move that gets it the
+	// furthest in a few turns of simulation.
+	"searcher": func() team {
+		boosted := false
+		return func(state *gameState, track map[int]*checkpoint, deadline time.Time) [2]command {
+			var cmds [2]command
+			for i, g := range state.players {
+				var boost bool
+				cmds[i], boost = searchRace(newSimPod(g), track, !boosted && !state.first)
+				boosted = boosted || boost
+			}
+			return cmds
+		}
+	},
+}
+
+// follow aims the pod at its checkpoint, full thrust unless it faces away.
+func follow(g gamer, track map[int]*checkpoint) command {
+	cp := track[g.nextCheckPointId].center
+	toCheckpoint := NewSmartVectorCartesian(float64(cp.x-g.x), float64(cp.y-g.y))
+	thrust := 100
+	if math.Abs(AngleDegrees(float64(g.angle)).Turn(AngleDegrees(toCheckpoint.angleDegrees()))) > 90 {
+		thrust = 20
+	}
+	return command{x: cp.x, y: cp.y, thrust: thrust}
+}
+
+// camp takes the pod to the checkpoint after target's, waits there facing
+// target, and goes for it once it is close.
+func camp(g, target gamer, track map[int]*checkpoint) command {
+	camp := track[(target.nextCheckPointId+1)%len(track)].center
+	toCamp := math.Hypot(float64(camp.x-g.x), float64(camp.y-g.y))
+	toTarget := math.Hypot(float64(target.x-g.x), float64(target.y-g.y))
+	switch {
+	case toTarget < 2500:
+		return command{x: target.x + target.vx, y: target.y + target.vy, thrust: 100}
+	case toCamp > 1500:
+		speed := math.Hypot(float64(g.vx), float64(g.vy))
+		thrust := 100
+		if toCamp < 6*speed {
+			thrust = 0 // drift in
+		}
+		return command{x: camp.x, y: camp.y, thrust: thrust}
+	default:
+		return command{x: target.x, y: target.y, thrust: 0}
+	}
+}
+
+const searchDepth = 4
+
+// searchThrusts are the thrusts the search tries with each rotation, besides
+// the boost.
+var searchThrusts = []int{0, 50, 100}
+
+// searchRace tries rotations and thrusts for the pod's next move, and the
+// boost if it is allowed, following each with searchDepth turns of the
+// rollouts' defaultPolicy, and returns the command that gets it the
+// furthest, and whether it boosts.
+func searchRace(p simPod, track map[int]*checkpoint, boostAllowed bool) (command, bool) {
+	best, bestProgress := podAction{}, math.Inf(-1)
+	for _, rotation := range []float64{-maxRotation, -maxRotation / 2, 0, maxRotation / 2, maxRotation} {
+		var actions []podAction
+		for _, thrust := range searchThrusts {
+			actions = append(actions, podAction{rotation: rotation, thrust: thrust})
+		}
+		if boostAllowed {
+			actions = append(actions, podAction{rotation: rotation, boost: true})
+		}
+		for _, action := range actions {
+			q := p
+			q.apply(action.command(p))
+			q.move(1, track)
+			q.endTurn()
+			for turn := 1; turn < searchDepth; turn++ {
+				q.apply(defaultPolicy(q, track))
+				q.move(1, track)
+				q.endTurn()
+			}
+			if progress := q.progress(track); progress > bestProgress {
+				best, bestProgress = action, progress
+			}
+		}
+	}
+	return best.command(p), best.boost
+}
+
+// zooNames are the bots of the zoo, sorted.
+func zooNames() []string {
+	var names []string
+	for name := range zoo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func zooCommand(args []string) {
+	flags := flag.NewFlagSet("zoo", flag.ExitOnError)
+	bot := flags.String("bot", "follower", "bot to play: "+strings.Join(zooNames(), ", "))
+	flags.Parse(args)
+
+	newBot, ok := zoo[*bot]
+	if !ok {
+		fmt.Println("no bot", *bot, "in the zoo")
+		os.Exit(1)
+	}
+	if err := runTeam(newBot(), os.Stdin, os.Stdout, turnBudget); err != nil {
+		fmt.Fprintln(os.Stderr, "game over:", err)
+	}
+}
+
+// versusRecord is how a strategy did against a bot.
+type versusRecord struct {
+	wins, games int
+	lead        float64 // the strategy's lead at the end, summed over the games
+}
+
+// playVersus races strategy s against the bot on games random tracks, each
+// side starting on the left half of the games.
+func playVersus(s, bot string, games int, seed int64, budget time.Duration) versusRecord {
+	var record versusRecord
+	rnd := rand.New(rand.NewSource(seed))
+	for game := 0; game < games; game++ {
+		track := randomTrack(rnd)
+		side := game % 2
+		var sides [2]team
+		sides[side], sides[1-side] = strategyTeam(s, policyNet), zoo[bot]()
+		result := playGame(track, gameLaps, sides, budget, nil)
+		record.games++
+		if result.winner == side {
+			record.wins++
+		}
+		record.lead += (result.progress[side] - result.progress[1-side]) / checkpointProgress
+	}
+	return record
+}
+
+func versus(args []string) {
+	flags := flag.NewFlagSet("versus", flag.ExitOnError)
+	s := flags.String("strategy", strategyHeuristic, "strategy raced against the zoo")
+	bots := flags.String("bots", strings.Join(zooNames(), ","), "bots of the zoo to race against, comma separated")
+	games := flags.Int("games", 20, "games against each bot")
+	seed := flags.Int64("seed", 1, "seed of the tracks")
+	budget := flags.Duration("budget", 0, "time each side gets per turn")
+	flags.Parse(args)
+
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	for _, bot := range strings.Split(*bots, ",") {
+		if _, ok := zoo[bot]; !ok {
+			fmt.Println("no bot", bot, "in the zoo")
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("%-10s %8s %10s\n", "bot", "wins", "lead")
+	for _, bot := range strings.Split(*bots, ",") {
+		record := playVersus(*s, bot, *games, *seed, *budget)
+		fmt.Printf("%-10s %4d/%-3d %10.2f\n", bot, record.wins, record.games, record.lead/float64(record.games))
+	}
+}
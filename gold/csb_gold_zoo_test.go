@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+func TestZooPlaysProtocol(t *testing.T) {
+	for _, game := range []string{"testdata/seed1.in", "testdata/bronze1.in"} {
+		in, err := ioutil.ReadFile(game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range zooNames() {
+			var out bytes.Buffer
+			if err := runTeam(zoo[name](), bytes.NewReader(in), &out, 0); err != nil {
+				t.Fatalf("%s on %s: %v", name, game, err)
+			}
+			checkOutput(t, out.Bytes())
+		}
+	}
+}
+
+func TestZooGamesFinish(t *testing.T) {
+	for _, name := range zooNames() {
+		track := randomTrack(rand.New(rand.NewSource(1)))
+		result := playGame(track, gameLaps, [2]team{zoo[name](), strategyTeam(strategyHeuristic, nil)}, 0, nil)
+		if result.winner < 0 {
+			t.Errorf("%s against the heuristics: no winner in %d turns", name, result.turns)
+		}
+	}
+}
+
+func TestSearcherBeatsFollower(t *testing.T) {
+	track := randomTrack(rand.New(rand.NewSource(1)))
+	result := playGame(track, gameLaps, [2]team{zoo["searcher"](), zoo["follower"]()}, 0, nil)
+	if result.winner != 0 {
+		t.Errorf("the follower beat the searcher: %+v", result)
+	}
+}
+
+func TestSearcherBoostsOnStraight(t *testing.T) {
+	track := map[int]*checkpoint{
+		0: {center: point{1000, 5000}, longDistanceAimpoint: point{1000, 5000}},
+		1: {center: point{14000, 5000}, longDistanceAimpoint: point{14000, 5000}},
+	}
+	calculateAimpoints(track)
+	p := newSimPod(gamer{x: 1000, y: 5000, nextCheckPointId: 1})
+	if cmd, boosted := searchRace(p, track, true); !boosted || !cmd.boost {
+		t.Errorf("no boost down a straight of 13000: %+v", cmd)
+	}
+	if cmd, boosted := searchRace(p, track, false); boosted || cmd.boost {
+		t.Errorf("boosted when it was not allowed: %+v", cmd)
+	}
+}
@@ -0,0 +1,343 @@
+// Package bronzebot is the bronze league's bot, racing one pod over the
+// single pod protocol. The bronze command runs it over its input, and the
+// gold referee races it in process.
+//
+// CodinGame takes a single file: the submission is this one, with the
+// package clause of a main package and bronze's main appended.
+package bronzebot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+type SmartVector struct {
+	x, y         float64
+	length       float64
+	angleDegrees float64
+	angleRadians float64
+}
+
+func NewSmartVectorCartesian(x, y float64) SmartVector {
+	angleRadians := cartesianToRadian(x, y)
+	angleDegrees := angleRadians * 180.0 / math.Pi
+	smartVector := SmartVector{
+		x:            x,
+		y:            y,
+		length:       math.Sqrt(x*x + y*y),
+		angleDegrees: angleDegrees,
+		angleRadians: angleRadians,
+	}
+	return smartVector
+}
+
+func NewSmartVectorPolar(length, angleDegrees float64) SmartVector {
+	angleRadians := normalizeAngleRadian(angleDegrees * math.Pi / 180)
+	angleDegrees = angleRadians * 180.0 / math.Pi
+	smartVector := SmartVector{
+		x:            length * math.Cos(angleRadians),
+		y:            length * math.Sin(angleRadians),
+		length:       length,
+		angleDegrees: angleDegrees,
+		angleRadians: angleRadians,
+	}
+	return smartVector
+}
+
+func (sv SmartVector) GetXYAsInts() (int, int) {
+	return int(sv.x), int(sv.y)
+}
+
+// cartesianToRadian is the angle of (x, y) in (-Pi, Pi], in the game's
+// convention: 0 is east and angles grow clockwise on screen, where y goes
+// down. Zero vectors, of either sign, point east, and infinite coordinates
+// keep their direction.
+func cartesianToRadian(x, y float64) float64 {
+	if x == 0 {
+		x = 0 // no negative zero, it would turn the angle around
+	}
+	if y == 0 {
+		y = 0
+	}
+	return math.Atan2(y, x)
+}
+
+func normalizeAngleRadian(angle float64) float64 {
+	// Normalize to game standard between -180 and 180 degrees
+	angle = math.Mod(angle, math.Pi*2)
+	if angle > math.Pi {
+		return angle - (math.Pi * 2)
+	}
+	if angle < (math.Pi * -1) {
+		return angle + (math.Pi * 2)
+	}
+	return angle
+}
+
+type checkpoint struct {
+	center               point
+	longDistanceAimpoint point
+	nextAimpoint         point
+}
+type point struct {
+	x int
+	y int
+}
+
+// gamer is a pod as the gold league's input has it, which we estimate from
+// the positions we are given.
+type gamer struct {
+	x, y, vx, vy, angle int
+}
+
+func (g gamer) currentSpeedV() SmartVector {
+	return NewSmartVectorCartesian(float64(g.vx), float64(g.vy))
+}
+
+// friction is the part of its speed a pod keeps from one turn to the next.
+const friction = 0.85
+
+// estimate is the pod at (x, y) that was prev last turn. The move it just
+// made is its speed before the friction, which the referee then truncates,
+// so vx and vy are at most 1 off unless the pod bounced off another. The
+// angle is the direction it moved in, our best guess at where the
+// opponent's pod faces; ours is worked out by heading.
+func estimate(prev gamer, x, y int) gamer {
+	g := gamer{
+		x:     x,
+		y:     y,
+		vx:    int(float64(x-prev.x) * friction),
+		vy:    int(float64(y-prev.y) * friction),
+		angle: prev.angle,
+	}
+	if x != prev.x || y != prev.y {
+		g.angle = heading(NewSmartVectorCartesian(float64(x-prev.x), float64(y-prev.y)), 0)
+	}
+	return g
+}
+
+// podRadius is the radius of the pods, which touch 2*podRadius apart.
+const podRadius = 400
+
+// meets is whether g and o, keeping their speeds, touch during the next
+// turn: whether they come closest within the turn less than 2*podRadius
+// apart.
+func (g gamer) meets(o gamer) bool {
+	dx, dy := float64(o.x-g.x), float64(o.y-g.y)
+	wx, wy := float64(o.vx-g.vx), float64(o.vy-g.vy)
+	t := 0.0
+	if w := wx*wx + wy*wy; w > 0 {
+		t = math.Max(0, math.Min(1, -(dx*wx+dy*wy)/w))
+	}
+	return math.Hypot(dx+wx*t, dy+wy*t) < 2*podRadius
+}
+
+// heading is the angle our pod faces, in [0, 360), from the direction to
+// the checkpoint and nextCheckpointAngle, the angle from our facing to it.
+func heading(targetV SmartVector, nextCheckpointAngle int) int {
+	angle := (int(math.Round(targetV.angleDegrees)) - nextCheckpointAngle) % 360
+	if angle < 0 {
+		angle += 360
+	}
+	return angle
+}
+
+type gameState struct {
+	first     bool
+	usedboost bool
+	player    gamer
+	opponent  gamer
+	// seen are the checkpoints in the order we were sent to them, until the
+	// first one comes round again and track is known.
+	seen  []point
+	track map[int]*checkpoint
+}
+
+// learnTrack records the checkpoint we are sent to. We start on the last
+// checkpoint of the lap, so the first one we are sent to coming round again
+// is the end of lap 1, and the checkpoints seen so far are the whole track.
+func (state *gameState) learnTrack(target point) {
+	if state.track != nil {
+		return
+	}
+	if len(state.seen) > 0 && state.seen[len(state.seen)-1] == target {
+		return
+	}
+	if len(state.seen) < 2 || state.seen[0] != target {
+		state.seen = append(state.seen, target)
+		return
+	}
+	track := make(map[int]*checkpoint)
+	for id, center := range state.seen {
+		track[id] = &checkpoint{center: center}
+	}
+	calculateAimpoints(track)
+	state.track = track
+}
+
+// checkpointAt is the checkpoint of the known track centered on target.
+func (state *gameState) checkpointAt(target point) *checkpoint {
+	for id := 0; id < len(state.track); id++ {
+		if state.track[id].center == target {
+			return state.track[id]
+		}
+	}
+	return nil
+}
+
+func calculateAimpoints(track map[int]*checkpoint) {
+	nextpoint := track[0]
+	for id := len(track) - 1; id >= 0; id-- {
+		currpoint := track[id]
+		ldaX := currpoint.center.x + ((currpoint.center.x - nextpoint.center.x) / 3)
+		ldaY := currpoint.center.y + ((currpoint.center.y - nextpoint.center.y) / 3)
+		track[id].longDistanceAimpoint.x = ldaX
+		track[id].longDistanceAimpoint.y = ldaY
+		track[id].nextAimpoint = nextpoint.center
+		nextpoint = currpoint
+	}
+	for id := 0; id < len(track); id++ {
+		fmt.Fprintf(os.Stderr, "checkpoint %d: %+v\n", id, *track[id])
+	}
+}
+
+// trackAim is where to aim for the checkpoint cp once the track is known:
+// at its long distance aimpoint while far off and heading that way, at the
+// next checkpoint when about to pass it. ok is false when neither applies.
+func trackAim(cp *checkpoint, x, y, nextCheckpointDist, nextCheckpointAngle int, targetV SmartVector) (SmartVector, bool) {
+	longDistanceAimV := NewSmartVectorCartesian(float64(cp.longDistanceAimpoint.x-x), float64(cp.longDistanceAimpoint.y-y))
+	viabilityAngle := longDistanceAimV.angleDegrees - targetV.angleDegrees
+	if viabilityAngle > 180 {
+		viabilityAngle -= 360
+	} else if viabilityAngle < -180 {
+		viabilityAngle += 360
+	}
+	if math.Abs(viabilityAngle) < 45 && nextCheckpointDist > 5500 {
+		fmt.Fprintf(os.Stderr, "USING SMARTDIRECTION: %+v\n", cp.longDistanceAimpoint)
+		return longDistanceAimV, true
+	}
+	if nextCheckpointDist < 1500 && math.Abs(float64(nextCheckpointAngle)) < 10 {
+		fmt.Fprintln(os.Stderr, "Oh so close, target next")
+		return NewSmartVectorCartesian(float64(cp.nextAimpoint.x-x), float64(cp.nextAimpoint.y-y)), true
+	}
+	return targetV, false
+}
+
+// Input is what the single pod protocol sends each turn: our pod, its next
+// checkpoint, the distance to it and the angle from our facing to it, and
+// the opponent's pod.
+type Input struct {
+	X, Y                                    int
+	NextCheckpointX, NextCheckpointY        int
+	NextCheckpointDist, NextCheckpointAngle int
+	OpponentX, OpponentY                    int
+}
+
+// Bot is the bronze bot through one game.
+type Bot struct {
+	state gameState
+}
+
+// New is the bot at the start of a game.
+func New() *Bot {
+	return &Bot{state: gameState{
+		first:     true,
+		usedboost: false,
+	}}
+}
+
+// Run plays the game read from in, writing our commands to out, until the
+// input ends.
+func Run(in io.Reader, out io.Writer) error {
+	input := bufio.NewReader(in)
+	bot := New()
+	for {
+		// nextCheckpointX: x position of the next check point
+		// nextCheckpointY: y position of the next check point
+		// nextCheckpointDist: distance to the next checkpoint
+		// nextCheckpointAngle: angle between your pod orientation and the direction of the next checkpoint
+		var turn Input
+		if _, err := fmt.Fscan(input, &turn.X, &turn.Y, &turn.NextCheckpointX, &turn.NextCheckpointY, &turn.NextCheckpointDist, &turn.NextCheckpointAngle); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fscan(input, &turn.OpponentX, &turn.OpponentY); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, bot.Turn(turn))
+	}
+}
+
+// Turn is our command for the turn of in, "X Y THRUST".
+func (bot *Bot) Turn(in Input) string {
+	state := &bot.state
+	x, y := in.X, in.Y
+	nextCheckpointX, nextCheckpointY := in.NextCheckpointX, in.NextCheckpointY
+	nextCheckpointDist, nextCheckpointAngle := in.NextCheckpointDist, in.NextCheckpointAngle
+	opponentX, opponentY := in.OpponentX, in.OpponentY
+
+	if state.first {
+		state.player = gamer{x: x, y: y}
+		state.opponent = gamer{x: opponentX, y: opponentY}
+		state.first = false
+	}
+	target := point{nextCheckpointX, nextCheckpointY}
+	state.learnTrack(target)
+	toOpponentV := NewSmartVectorCartesian(float64(opponentX-x), float64(opponentY-y))
+	targetV := NewSmartVectorCartesian(float64(nextCheckpointX-x), float64(nextCheckpointY-y))
+	state.player = estimate(state.player, x, y)
+	state.player.angle = heading(targetV, nextCheckpointAngle)
+	state.opponent = estimate(state.opponent, opponentX, opponentY)
+	lastMoveV := state.player.currentSpeedV()
+	if lastMoveV.length < 10 {
+		lastMoveV = targetV
+	}
+	fmt.Fprintf(os.Stderr, "nextCheckpointAngle: %d\n", nextCheckpointAngle)
+	aimed := false
+	if cp := state.checkpointAt(target); cp != nil {
+		targetV, aimed = trackAim(cp, x, y, nextCheckpointDist, nextCheckpointAngle, targetV)
+	}
+	if !aimed && math.Abs(float64(nextCheckpointAngle)) < 20 {
+		desiredAngle := targetV.angleDegrees
+		deltaAngle := desiredAngle - lastMoveV.angleDegrees
+		fmt.Fprintf(os.Stderr, "deltaAngle: %f, lastMoveV.angleDegrees: %f\n", deltaAngle, lastMoveV.angleDegrees)
+		newTargetAngle := desiredAngle + (float64(deltaAngle))
+		targetV = NewSmartVectorPolar(targetV.length, newTargetAngle)
+		fmt.Fprintf(os.Stderr, "desiredAngle: %f, newTargetAngle: %f\n", desiredAngle, newTargetAngle)
+		fmt.Fprintf(os.Stderr, "nextCheckpointX: %d, nextCheckpointX: %d,\n", nextCheckpointX, nextCheckpointY)
+		fmt.Fprintf(os.Stderr, "targetV.x: %d, targetV.y: %d\n", int(targetV.x), int(targetV.y))
+		fmt.Fprintf(os.Stderr, "nextx: %d, nexty: %d\n", x+int(targetV.x), y+int(targetV.y))
+	}
+
+	// fmt.Fprintln(os.Stderr, "Debug messages...")
+	thrust := 100
+	if nextCheckpointDist < 0 {
+		thrust = 0 // not a distance, the input is broken
+	} else if nextCheckpointDist < 2000 {
+		thrust = 100 * (nextCheckpointDist + 100) / 2100
+		fmt.Fprintln(os.Stderr, "distancethrust:", thrust)
+	}
+	if nextCheckpointAngle > 90 || nextCheckpointAngle < -90 {
+		thrust = 1
+	}
+	// You have to output the target position
+	// followed by the power (0 <= thrust <= 100) or "BOOST"
+	// i.e.: "x y thrust"
+	targetX, targetY := targetV.GetXYAsInts()
+	fmt.Fprintf(os.Stderr, "usedboost: %t", state.usedboost)
+	useboost := !state.usedboost && nextCheckpointDist > 4500 && nextCheckpointAngle < 5 && nextCheckpointAngle > -5 && toOpponentV.length > 2500
+	// shield near the checkpoint when the opponent is about to hit us
+	useshield := nextCheckpointDist+int(toOpponentV.length) < 2000 && state.player.meets(state.opponent)
+	if useboost {
+		state.usedboost = true
+		return fmt.Sprintf("%d %d BOOST", x+targetX, y+targetY)
+	} else if useshield {
+		return fmt.Sprintf("%d %d SHIELD", x+targetX, y+targetY)
+	}
+	return fmt.Sprintf("%d %d %d", x+targetX, y+targetY, thrust)
+}
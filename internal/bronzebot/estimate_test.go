@@ -0,0 +1,66 @@
+package bronzebot
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEstimate drives a pod around with the referee's physics and checks the
+// estimates against what the gold league's input would have said.
+func TestEstimate(t *testing.T) {
+	targets := []point{{8000, 2000}, {13000, 7000}, {3000, 6000}}
+	px, py, vx, vy, angle := 2000.0, 4000.0, 0.0, 0.0, 90.0
+	pod := gamer{x: 2000, y: 4000}
+	for turn := 0; turn < 60; turn++ {
+		target := targets[turn/20]
+		toTargetV := NewSmartVectorCartesian(float64(target.x)-px, float64(target.y)-py)
+		nextCheckpointAngle := int(math.Round(math.Remainder(toTargetV.angleDegrees-angle, 360)))
+		pod = estimate(pod, int(px), int(py))
+		pod.angle = heading(toTargetV, nextCheckpointAngle)
+		if math.Abs(float64(pod.vx)-vx) > 1 || math.Abs(float64(pod.vy)-vy) > 1 {
+			t.Errorf("turn %d: speed estimated (%d, %d), is (%.0f, %.0f)", turn, pod.vx, pod.vy, vx, vy)
+		}
+		if off := math.Abs(math.Remainder(float64(pod.angle)-angle, 360)); off > 1 {
+			t.Errorf("turn %d: heading estimated %d, is %.0f", turn, pod.angle, angle)
+		}
+
+		// The referee's turn: turn by at most 18 degrees, thrust 100, move,
+		// round the position and slow down.
+		turnBy := math.Max(-18, math.Min(18, math.Remainder(toTargetV.angleDegrees-angle, 360)))
+		angle = math.Mod(math.Round(angle+turnBy)+360, 360)
+		vx += 100 * math.Cos(angle*math.Pi/180)
+		vy += 100 * math.Sin(angle*math.Pi/180)
+		px, py = math.Round(px+vx), math.Round(py+vy)
+		vx, vy = math.Trunc(vx*friction), math.Trunc(vy*friction)
+	}
+}
+
+func TestEstimateOpponent(t *testing.T) {
+	opponent := estimate(gamer{x: 1000, y: 1000, angle: 45}, 1000, 1000)
+	if opponent.vx != 0 || opponent.vy != 0 || opponent.angle != 45 {
+		t.Errorf("standing still: got %+v", opponent)
+	}
+	opponent = estimate(opponent, 1000, 1500)
+	if opponent.vx != 0 || opponent.vy != 425 || opponent.angle != 90 {
+		t.Errorf("moving down: got %+v", opponent)
+	}
+}
+
+func TestMeets(t *testing.T) {
+	pod := gamer{x: 5000, y: 5000, vx: 300}
+	for _, c := range []struct {
+		opponent gamer
+		want     bool
+	}{
+		{gamer{x: 6500, y: 5000, vx: -500}, true},          // head on, 700 apart at the end of the turn
+		{gamer{x: 6500, y: 5000, vx: 500}, false},          // running away
+		{gamer{x: 6000, y: 5000, vx: 1000}, false},         // 1000 apart, getting further
+		{gamer{x: 5300, y: 6500, vx: 300, vy: -900}, true}, // from the side, closest mid turn
+		{gamer{x: 5300, y: 6500, vx: 300, vy: -400}, false},
+		{gamer{x: 5500, y: 5000}, true}, // already touching
+	} {
+		if got := pod.meets(c.opponent); got != c.want {
+			t.Errorf("meets %+v: %v, want %v", c.opponent, got, c.want)
+		}
+	}
+}
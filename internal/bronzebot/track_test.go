@@ -0,0 +1,37 @@
+package bronzebot
+
+import "testing"
+
+func TestLearnTrack(t *testing.T) {
+	cp0, cp1, cp2 := point{1000, 1000}, point{8000, 2000}, point{5000, 7000}
+	var state gameState
+	// We start on cp0, sent to cp1, and stay sent somewhere for many turns.
+	for _, target := range []point{cp1, cp1, cp2, cp2, cp2, cp0, cp0} {
+		state.learnTrack(target)
+		if state.track != nil {
+			t.Fatalf("track known after %v, before lap 1 is over", state.seen)
+		}
+	}
+	state.learnTrack(cp1)
+	if len(state.track) != 3 {
+		t.Fatalf("got a track of %d checkpoints, want 3", len(state.track))
+	}
+	for id, want := range []point{cp1, cp2, cp0} {
+		if got := state.track[id].center; got != want {
+			t.Errorf("checkpoint %d at %v, want %v", id, got, want)
+		}
+	}
+	if got := state.checkpointAt(cp2).nextAimpoint; got != cp0 {
+		t.Errorf("after cp2 aim at %v, want %v", got, cp0)
+	}
+	if got := state.checkpointAt(cp0).nextAimpoint; got != cp1 {
+		t.Errorf("after cp0 aim at %v, want %v", got, cp1)
+	}
+	if state.checkpointAt(point{1, 1}) != nil {
+		t.Error("found a checkpoint that is not on the track")
+	}
+	state.learnTrack(point{1, 1})
+	if len(state.track) != 3 {
+		t.Error("the known track changed")
+	}
+}
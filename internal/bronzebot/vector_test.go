@@ -0,0 +1,54 @@
+package bronzebot
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"codeingame-csb/internal/proptest"
+)
+
+var (
+	genCoordinate = proptest.Gen{Min: -1e6, Max: 1e6}
+	genLength     = proptest.Gen{Min: 0, Max: 1e6}
+	genDegrees    = proptest.Gen{Min: -1e4, Max: 1e4}
+)
+
+// Bronze's vector code is the smallest copy: only the constructors.
+func TestVectorProperties(t *testing.T) {
+	t.Run("polarCartesianRoundTrip", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genLength, genDegrees}, func(args []float64) error {
+			sv := NewSmartVectorPolar(args[0], args[1])
+			back := NewSmartVectorCartesian(sv.x, sv.y)
+			if !proptest.Close(back.length, args[0], args[0]) {
+				return fmt.Errorf("length %v came back as %v", args[0], back.length)
+			}
+			if turn := math.Remainder(back.angleDegrees-args[1], 360); args[0] > 0 && !proptest.Close(turn, 0, math.Abs(args[1])) {
+				return fmt.Errorf("angle %v came back as %v", args[1], back.angleDegrees)
+			}
+			return nil
+		})
+	})
+	t.Run("angleNormalizationRange", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate, genLength, genDegrees}, func(args []float64) error {
+			sv := NewSmartVectorCartesian(args[0], args[1])
+			if !(sv.angleDegrees > -180 && sv.angleDegrees <= 180) || !(sv.angleRadians > -math.Pi && sv.angleRadians <= math.Pi) {
+				return fmt.Errorf("angle %v degrees, %v radians out of range", sv.angleDegrees, sv.angleRadians)
+			}
+			polar := NewSmartVectorPolar(args[2], args[3])
+			if !(polar.angleDegrees >= -180 && polar.angleDegrees <= 180) || !(polar.angleRadians >= -math.Pi && polar.angleRadians <= math.Pi) {
+				return fmt.Errorf("polar angle %v is %v degrees, %v radians", args[3], polar.angleDegrees, polar.angleRadians)
+			}
+			return nil
+		})
+	})
+	t.Run("lengthNonNegative", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate}, func(args []float64) error {
+			sv := NewSmartVectorCartesian(args[0], args[1])
+			if sv.length < 0 || !proptest.Close(sv.length, math.Hypot(args[0], args[1]), sv.length) {
+				return fmt.Errorf("length is %v", sv.length)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,131 @@
+// Package proptest checks properties of the vector code against random
+// inputs. Each league keeps its own copy of the vector code, and each runs
+// the same properties with this package on its copy.
+//
+// A failing property is shrunk to the simplest input that still fails it,
+// and reported with the seed that reproduces the run:
+//
+//	PROPTEST_SEED=1234 go test ./gold -run TestVectorProperties
+package proptest
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Runs is how many random inputs each property is checked with.
+var Runs = 1000
+
+// Gen draws a float64 in [Min, Max], and now and then one of the values at
+// the edges of the range that bugs like best.
+type Gen struct {
+	Min, Max float64
+}
+
+func (g Gen) draw(rnd *rand.Rand) float64 {
+	if rnd.Intn(8) == 0 {
+		edges := []float64{g.Min, g.Max, 0, math.Copysign(0, -1), 1, -1}
+		for {
+			if v := edges[rnd.Intn(len(edges))]; v >= g.Min && v <= g.Max {
+				return v
+			}
+		}
+	}
+	return g.Min + rnd.Float64()*(g.Max-g.Min)
+}
+
+// simpler lists values closer to simple than v that are still in range:
+// zero, or Min if zero isn't, v without its fraction, v halfway there and
+// v one step closer.
+func (g Gen) simpler(v float64) []float64 {
+	simplest := 0.0
+	if simplest < g.Min {
+		simplest = g.Min
+	} else if simplest > g.Max {
+		simplest = g.Max
+	}
+	var candidates []float64
+	for _, c := range []float64{simplest, math.Trunc(v), simplest + (v-simplest)/2, v - math.Copysign(1, v-simplest)} {
+		if c != v && c >= g.Min && c <= g.Max && math.Abs(c-simplest) < math.Abs(v-simplest) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// Property checks one input, an error tells what doesn't hold.
+type Property func(args []float64) error
+
+// failure is a property that didn't hold, with the input as drawn and as
+// shrunk.
+type failure struct {
+	drawn, shrunk []float64
+	err           error
+}
+
+func run(seed int64, runs int, gens []Gen, property Property) *failure {
+	rnd := rand.New(rand.NewSource(seed))
+	args := make([]float64, len(gens))
+	for i := 0; i < runs; i++ {
+		for j, g := range gens {
+			args[j] = g.draw(rnd)
+		}
+		if err := property(args); err != nil {
+			drawn := append([]float64(nil), args...)
+			return &failure{drawn: drawn, shrunk: args, err: shrink(gens, property, args, err)}
+		}
+	}
+	return nil
+}
+
+// shrink simplifies the failing args in place, one argument at a time, for
+// as long as the property keeps failing, and returns the last error.
+func shrink(gens []Gen, property Property, args []float64, err error) error {
+	for progress := true; progress; {
+		progress = false
+		for j, g := range gens {
+			for _, candidate := range g.simpler(args[j]) {
+				previous := args[j]
+				args[j] = candidate
+				if candidateErr := property(args); candidateErr != nil {
+					err, progress = candidateErr, true
+					break
+				}
+				args[j] = previous
+			}
+		}
+	}
+	return err
+}
+
+// seed is the seed of this test run: PROPTEST_SEED when it is set, the
+// clock otherwise.
+var seed = func() int64 {
+	if s, err := strconv.ParseInt(os.Getenv("PROPTEST_SEED"), 10, 64); err == nil {
+		return s
+	}
+	return time.Now().UnixNano()
+}()
+
+// Check checks the property against Runs inputs drawn by gens, one float64
+// per Gen. Each test derives its own inputs from the run's seed, so the seed
+// reproduces any failure.
+func Check(t *testing.T, gens []Gen, property Property) {
+	t.Helper()
+	name := fnv.New64a()
+	name.Write([]byte(t.Name()))
+	if f := run(seed^int64(name.Sum64()), Runs, gens, property); f != nil {
+		t.Fatalf("%v\nfor %v, shrunk from %v\nreproduce with PROPTEST_SEED=%d", f.err, f.shrunk, f.drawn, seed)
+	}
+}
+
+// Close tells whether a and b are equal but for rounding, relative to the
+// magnitude of the numbers they were computed from.
+func Close(a, b, magnitude float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, magnitude)
+}
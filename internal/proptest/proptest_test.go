@@ -0,0 +1,40 @@
+package proptest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShrinksToSimplestFailure(t *testing.T) {
+	gens := []Gen{{-1000, 1000}, {5, 100}}
+	property := func(args []float64) error {
+		if args[0] > 10.5 {
+			return errors.New("too big")
+		}
+		return nil
+	}
+	f := run(1, 1000, gens, property)
+	if f == nil {
+		t.Fatal("property held for all inputs")
+	}
+	if f.shrunk[0] != 11 || f.shrunk[1] != 5 {
+		t.Errorf("shrunk %v to %v, want [11 5]", f.drawn, f.shrunk)
+	}
+}
+
+func TestSameSeedSameInputs(t *testing.T) {
+	var first, second []float64
+	record := func(into *[]float64) Property {
+		return func(args []float64) error {
+			*into = append(*into, args[0])
+			return nil
+		}
+	}
+	run(42, 50, []Gen{{0, 1}}, record(&first))
+	run(42, 50, []Gen{{0, 1}}, record(&second))
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("input %d is %v, then %v", i, first[i], second[i])
+		}
+	}
+}
@@ -48,11 +48,7 @@ func (sv SmartVector) GetXYAsInts() (int, int) {
 }
 
 func (sv SmartVector) multiplyNumber(factor float64) SmartVector {
-	if sv.length == 0 || math.IsNaN(sv.angleRadians) {
-		return NewSmartVectorCartesian(0, 0)
-	} else {
-		return NewSmartVectorCartesian(sv.x * factor, sv.y * factor)
-	}
+	return NewSmartVectorCartesian(sv.x*factor, sv.y*factor)
 }
 
 func (sv SmartVector) addVector(otherVector SmartVector) SmartVector {
@@ -63,19 +59,218 @@ func (sv SmartVector) subtractVector(otherVector SmartVector) SmartVector {
 	return NewSmartVectorCartesian(sv.x-otherVector.x, sv.y-otherVector.y)
 }
 
+// Vec2 is a plain vector for the physics and the searches. Unlike
+// SmartVector it works nothing out up front: the length and the angles are
+// only computed when asked for.
+type Vec2 struct {
+	X, Y float64
+}
+
+// Vec2Polar is the vector of the given length pointing at angleDegrees.
+func Vec2Polar(length, angleDegrees float64) Vec2 {
+	sin, cos := math.Sincos(angleDegrees * math.Pi / 180)
+	return Vec2{length * cos, length * sin}
+}
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec2) Scale(factor float64) Vec2 {
+	return Vec2{v.X * factor, v.Y * factor}
+}
+
+func (v Vec2) Dot(o Vec2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Cross is the z of the 3D cross product, positive when o is at a positive
+// angle from v.
+func (v Vec2) Cross(o Vec2) float64 {
+	return v.X*o.Y - v.Y*o.X
+}
+
+func (v Vec2) LengthSquared() float64 {
+	return v.X*v.X + v.Y*v.Y
+}
+
+func (v Vec2) Length() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+}
+
+func (v Vec2) Distance(o Vec2) float64 {
+	return v.Sub(o).Length()
+}
+
+func (v Vec2) DistanceSquared(o Vec2) float64 {
+	return v.Sub(o).LengthSquared()
+}
+
+// Normalize is the vector of length 1 in the same direction; the zero
+// vector stays zero.
+func (v Vec2) Normalize() Vec2 {
+	length := v.Length()
+	if length == 0 {
+		return v
+	}
+	return Vec2{v.X / length, v.Y / length}
+}
+
+// Radians is the angle of the vector in (-Pi, Pi], 0 for the zero vector.
+func (v Vec2) Radians() float64 {
+	return cartesianToRadian(v.X, v.Y)
+}
+
+// Degrees is the angle of the vector in (-180, 180], 0 for the zero vector.
+func (v Vec2) Degrees() float64 {
+	return cartesianToRadian(v.X, v.Y) * 180 / math.Pi
+}
+
+func (v Vec2) Angle() Angle {
+	return AngleRadians(cartesianToRadian(v.X, v.Y))
+}
+
+func (v Vec2) Rotate(degrees float64) Vec2 {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	return Vec2{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Perpendicular is the vector rotated by 90 degrees.
+func (v Vec2) Perpendicular() Vec2 {
+	return Vec2{-v.Y, v.X}
+}
+
+// AngleBetween is the angle in degrees, in (-180, 180], to turn v by to
+// point along o.
+func (v Vec2) AngleBetween(o Vec2) float64 {
+	return math.Atan2(v.Cross(o), v.Dot(o)) * 180 / math.Pi
+}
+
+// Project is the part of v along onto; zero when onto is.
+func (v Vec2) Project(onto Vec2) Vec2 {
+	lengthSquared := onto.LengthSquared()
+	if lengthSquared == 0 {
+		return Vec2{}
+	}
+	return onto.Scale(v.Dot(onto) / lengthSquared)
+}
+
+// Reflect is v bounced off a wall with the given normal, which doesn't need
+// to be of length 1.
+func (v Vec2) Reflect(normal Vec2) Vec2 {
+	return v.Sub(v.Project(normal).Scale(2))
+}
+
+// Lerp is the point t of the way from v to o.
+func (v Vec2) Lerp(o Vec2, t float64) Vec2 {
+	return Vec2{v.X + (o.X-v.X)*t, v.Y + (o.Y-v.Y)*t}
+}
+
+func (v Vec2) SmartVector() SmartVector {
+	return NewSmartVectorCartesian(v.X, v.Y)
+}
+
+func (sv SmartVector) Vec2() Vec2 {
+	return Vec2{sv.x, sv.y}
+}
+
+// Angle is a direction, kept in degrees in [0, 360) like the pods' angles in
+// the game's input: 0 is east and angles grow clockwise on screen, where y
+// goes down.
+type Angle struct {
+	degrees float64
+}
+
+func AngleDegrees(degrees float64) Angle {
+	return Angle{wrapDegrees(degrees)}
+}
+
+func AngleRadians(radians float64) Angle {
+	return Angle{wrapDegrees(radians * 180 / math.Pi)}
+}
+
+// wrapDegrees brings any number of degrees into [0, 360).
+func wrapDegrees(degrees float64) float64 {
+	if degrees < -360 || degrees >= 720 {
+		degrees = math.Mod(degrees, 360)
+	}
+	if degrees < 0 {
+		degrees += 360
+	} else if degrees >= 360 {
+		degrees -= 360
+	}
+	if degrees >= 360 {
+		return 0 // a tiny negative angle plus 360 rounds up to 360
+	}
+	return degrees
+}
+
+func (a Angle) Degrees() float64 {
+	return a.degrees
+}
+
+func (a Angle) Radians() float64 {
+	return a.degrees * math.Pi / 180
+}
+
+// Add turns the angle by the given degrees.
+func (a Angle) Add(degrees float64) Angle {
+	return AngleDegrees(a.degrees + degrees)
+}
+
+// Turn is how many degrees, in [-180, 180), to turn from a to face to.
+func (a Angle) Turn(to Angle) float64 {
+	delta := to.degrees - a.degrees
+	if delta >= 180 {
+		delta -= 360
+	} else if delta < -180 {
+		delta += 360
+	}
+	return delta
+}
+
+// Towards turns from a to face to, by at most maxTurn degrees.
+func (a Angle) Towards(to Angle, maxTurn float64) Angle {
+	turn := a.Turn(to)
+	if turn > maxTurn {
+		turn = maxTurn
+	} else if turn < -maxTurn {
+		turn = -maxTurn
+	}
+	return a.Add(turn)
+}
+
+// Lerp is the angle t of the way from a to to, turning the short way.
+func (a Angle) Lerp(to Angle, t float64) Angle {
+	return a.Add(a.Turn(to) * t)
+}
+
+// Round is the angle to the whole degree, as the referee keeps it.
+func (a Angle) Round() Angle {
+	return AngleDegrees(math.Round(a.degrees))
+}
+
+// cartesianToRadian is the angle of (x, y) in (-Pi, Pi], in the game's
+// convention: 0 is east and angles grow clockwise on screen, where y goes
+// down. Zero vectors, of either sign, point east, and infinite coordinates
+// keep their direction.
 func cartesianToRadian(x, y float64) float64 {
-	angleRadians := math.Atan(y / x)
-	if x < 0 && y >= 0 {
-		return angleRadians + math.Pi
-	} // quadrant 2
-	if x < 0 && y < 0 {
-		return angleRadians - math.Pi
-	} // quadrant 3
-	return angleRadians // default for quadrant 1 and quadrant 4
+	if x == 0 {
+		x = 0 // no negative zero, it would turn the angle around
+	}
+	if y == 0 {
+		y = 0
+	}
+	return math.Atan2(y, x)
 }
 
 func normalizeAngleRadian(angle float64) float64 {
 	// Normalize to game standard between -180 and 180 degrees
+	angle = math.Mod(angle, math.Pi*2)
 	if angle > math.Pi {
 		return angle - (math.Pi * 2)
 	}
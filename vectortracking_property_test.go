@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"codeingame-csb/internal/proptest"
+)
+
+var (
+	genCoordinate = proptest.Gen{Min: -1e6, Max: 1e6}
+	genLength     = proptest.Gen{Min: 0, Max: 1e6}
+	genDegrees    = proptest.Gen{Min: -1e4, Max: 1e4}
+	genFactor     = proptest.Gen{Min: -100, Max: 100}
+)
+
+func TestVectorProperties(t *testing.T) {
+	t.Run("polarCartesianRoundTrip", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genLength, genDegrees}, func(args []float64) error {
+			sv := NewSmartVectorPolar(args[0], args[1])
+			back := NewSmartVectorCartesian(sv.x, sv.y)
+			if !proptest.Close(back.length, args[0], args[0]) {
+				return fmt.Errorf("length %v came back as %v", args[0], back.length)
+			}
+			if turn := AngleDegrees(back.angleDegrees).Turn(AngleDegrees(args[1])); args[0] > 0 && !proptest.Close(turn, 0, math.Abs(args[1])) {
+				return fmt.Errorf("angle %v came back as %v", args[1], back.angleDegrees)
+			}
+			return nil
+		})
+	})
+	t.Run("addSubtractInverse", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate, genCoordinate, genCoordinate}, func(args []float64) error {
+			a, b := NewSmartVectorCartesian(args[0], args[1]), NewSmartVectorCartesian(args[2], args[3])
+			back := a.addVector(b).subtractVector(b)
+			magnitude := math.Max(a.length, b.length)
+			if !proptest.Close(back.x, a.x, magnitude) || !proptest.Close(back.y, a.y, magnitude) {
+				return fmt.Errorf("a + b - b is %v, not %v", back.Vec2(), a.Vec2())
+			}
+			if v := a.Vec2().Add(b.Vec2()).Sub(b.Vec2()); !proptest.Close(v.X, a.x, magnitude) || !proptest.Close(v.Y, a.y, magnitude) {
+				return fmt.Errorf("Vec2 a + b - b is %v, not %v", v, a.Vec2())
+			}
+			return nil
+		})
+	})
+	t.Run("scalingLinearity", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate, genCoordinate, genCoordinate, genFactor}, func(args []float64) error {
+			a, b, k := NewSmartVectorCartesian(args[0], args[1]), NewSmartVectorCartesian(args[2], args[3]), args[4]
+			sum := a.addVector(b).multiplyNumber(k)
+			separate := a.multiplyNumber(k).addVector(b.multiplyNumber(k))
+			magnitude := math.Abs(k) * math.Max(a.length, b.length)
+			if !proptest.Close(sum.x, separate.x, magnitude) || !proptest.Close(sum.y, separate.y, magnitude) {
+				return fmt.Errorf("k(a + b) is %v, ka + kb is %v", sum.Vec2(), separate.Vec2())
+			}
+			if scaled := a.multiplyNumber(k); !proptest.Close(scaled.length, math.Abs(k)*a.length, magnitude) {
+				return fmt.Errorf("|ka| is %v, |k||a| is %v", scaled.length, math.Abs(k)*a.length)
+			}
+			return nil
+		})
+	})
+	t.Run("angleNormalizationRange", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate, genDegrees}, func(args []float64) error {
+			sv := NewSmartVectorCartesian(args[0], args[1])
+			if !(sv.angleDegrees > -180 && sv.angleDegrees <= 180) || !(sv.angleRadians > -math.Pi && sv.angleRadians <= math.Pi) {
+				return fmt.Errorf("angle %v degrees, %v radians out of range", sv.angleDegrees, sv.angleRadians)
+			}
+			if r := normalizeAngleRadian(args[2] * math.Pi / 180); !(r >= -math.Pi && r <= math.Pi) {
+				return fmt.Errorf("normalizeAngleRadian gives %v", r)
+			}
+			if a := AngleDegrees(args[2]).Degrees(); !(a >= 0 && a < 360) {
+				return fmt.Errorf("Angle is %v", a)
+			}
+			if turn := sv.Vec2().Angle().Turn(AngleDegrees(sv.angleDegrees)); !proptest.Close(turn, 0, 180) {
+				return fmt.Errorf("Vec2 angle is off the SmartVector's by %v", turn)
+			}
+			return nil
+		})
+	})
+	t.Run("lengthNonNegative", func(t *testing.T) {
+		proptest.Check(t, []proptest.Gen{genCoordinate, genCoordinate, genDegrees}, func(args []float64) error {
+			sv := NewSmartVectorCartesian(args[0], args[1])
+			if sv.length < 0 || !proptest.Close(sv.length, math.Hypot(args[0], args[1]), sv.length) {
+				return fmt.Errorf("length is %v", sv.length)
+			}
+			if rotated := sv.Vec2().Rotate(args[2]).Length(); !proptest.Close(rotated, sv.length, sv.length) {
+				return fmt.Errorf("rotating changes the length to %v", rotated)
+			}
+			return nil
+		})
+	})
+}
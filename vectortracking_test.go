@@ -27,8 +27,8 @@ func TestSubtractVector(t *testing.T) {
 		assertFloatPrettyEqual(t, "x value", 0, resV.x)
 		assertFloatPrettyEqual(t, "y value", 0, resV.y)
 		assertFloatPrettyEqual(t, "length", 0, resV.length)
-		assertTrue(t,"angleDegrees is NaN", math.IsNaN(resV.angleDegrees))
-		assertTrue(t,"angleRadians is NaN", math.IsNaN(resV.angleRadians))
+		assertFloatPrettyEqual(t, "angleDegrees", 0, resV.angleDegrees)
+		assertFloatPrettyEqual(t, "angleRadians", 0, resV.angleRadians)
 	})
 	t.Run("NullVMinusNormV", func(t *testing.T) {
 		sv1 := NewSmartVectorCartesian(0, 0)
@@ -74,8 +74,8 @@ func TestAddVector(t *testing.T) {
 		assertFloatPrettyEqual(t, "x value", 0, resV.x)
 		assertFloatPrettyEqual(t, "y value", 0, resV.y)
 		assertFloatPrettyEqual(t, "length", 0, resV.length)
-		assertTrue(t,"angleDegrees is NaN", math.IsNaN(resV.angleDegrees))
-		assertTrue(t,"angleRadians is NaN", math.IsNaN(resV.angleRadians))
+		assertFloatPrettyEqual(t, "angleDegrees", 0, resV.angleDegrees)
+		assertFloatPrettyEqual(t, "angleRadians", 0, resV.angleRadians)
 	})
 	t.Run("NullVPlusNormV", func(t *testing.T) {
 		sv1 := NewSmartVectorCartesian(0, 0)
@@ -96,8 +96,8 @@ func TestAddVector(t *testing.T) {
 		assertFloatPrettyEqual(t, "x value", 0, resV.x)
 		assertFloatPrettyEqual(t, "y value", 0, resV.y)
 		assertFloatPrettyEqual(t, "length", 0, resV.length)
-		assertTrue(t,"angleDegrees is NaN", math.IsNaN(resV.angleDegrees))
-		assertTrue(t,"angleRadians is NaN", math.IsNaN(resV.angleRadians))
+		assertFloatPrettyEqual(t, "angleDegrees", 0, resV.angleDegrees)
+		assertFloatPrettyEqual(t, "angleRadians", 0, resV.angleRadians)
 	})
 	t.Run("NegVPlusNegV", func(t *testing.T) {
 		sv1 := NewSmartVectorCartesian(-5, -10)
@@ -120,8 +120,8 @@ func TestMultiplyByNumber(t *testing.T) {
 		assertFloatPrettyEqual(t, "x value", 0, resV.x)
 		assertFloatPrettyEqual(t, "y value", 0, resV.y)
 		assertFloatPrettyEqual(t, "length", 0, resV.length)
-		assertTrue(t,"angleDegrees is NaN", math.IsNaN(resV.angleDegrees))
-		assertTrue(t,"angleRadians is NaN", math.IsNaN(resV.angleRadians))
+		assertFloatPrettyEqual(t, "angleDegrees", 0, resV.angleDegrees)
+		assertFloatPrettyEqual(t, "angleRadians", 0, resV.angleRadians)
 	})
 	t.Run("NormVX0", func(t *testing.T) {
 		sv := NewSmartVectorCartesian(-10, 10)
@@ -130,8 +130,8 @@ func TestMultiplyByNumber(t *testing.T) {
 		assertFloatPrettyEqual(t, "x value", 0, resV.x)
 		assertFloatPrettyEqual(t, "y value", 0, resV.y)
 		assertFloatPrettyEqual(t, "length", 0, resV.length)
-		assertTrue(t,"angleDegrees is NaN", math.IsNaN(resV.angleDegrees))
-		assertTrue(t,"angleRadians is NaN", math.IsNaN(resV.angleRadians))
+		assertFloatPrettyEqual(t, "angleDegrees", 0, resV.angleDegrees)
+		assertFloatPrettyEqual(t, "angleRadians", 0, resV.angleRadians)
 	})
 	t.Run("NormVX10", func(t *testing.T) {
 		sv := NewSmartVectorCartesian(-10, 10)
@@ -218,7 +218,7 @@ func TestNewSmartVectorCartesian(t *testing.T) {
 	t.Run("shouldGenerate_0_0", func(t *testing.T) {
 		sv := NewSmartVectorCartesian(0, 0)
 		assertTrue(t,"Length is one", sv.length == 0)
-		assertTrue(t,"Angle is NaN", math.IsNaN(sv.angleDegrees))
+		assertTrue(t,"Angle is 0 degrees", sv.angleDegrees == 0)
 	})
 	t.Run("shouldGenerate_1_0", func(t *testing.T) {
 		sv := NewSmartVectorCartesian(1, 0)
@@ -260,4 +260,120 @@ func TestNewSmartVectorCartesian(t *testing.T) {
 		assertTrue(t,"Length is one", sv.length == math.Sqrt(2))
 		assertTrue(t,"Angle is -135 degrees", sv.angleDegrees == -135)
 	})
-}
\ No newline at end of file
+}
+func TestVec2(t *testing.T) {
+	t.Run("shouldDotAndCross", func(t *testing.T) {
+		v := Vec2{3, 4}
+		assertFloatPrettyEqual(t, "dot", 11, v.Dot(Vec2{1, 2}))
+		assertFloatPrettyEqual(t, "cross", 2, v.Cross(Vec2{1, 2}))
+		assertFloatPrettyEqual(t, "length", 5, v.Length())
+		assertFloatPrettyEqual(t, "distance", 5, v.Distance(Vec2{0, 0}))
+	})
+	t.Run("shouldRotate_90", func(t *testing.T) {
+		v := Vec2{1, 0}.Rotate(90)
+		assertFloatPrettyEqual(t, "x value", 0, v.X)
+		assertFloatPrettyEqual(t, "y value", 1, v.Y)
+		assertTrue(t, "perpendicular is rotated by 90", Vec2{1, 0}.Perpendicular() == Vec2{0, 1})
+	})
+	t.Run("shouldNormalize_0_0", func(t *testing.T) {
+		v := Vec2{}.Normalize()
+		assertTrue(t, "zero stays zero", v == Vec2{})
+		assertFloatPrettyEqual(t, "degrees", 0, Vec2{}.Degrees())
+	})
+	t.Run("shouldNormalize_-3_4", func(t *testing.T) {
+		v := Vec2{-3, 4}.Normalize()
+		assertFloatPrettyEqual(t, "x value", -0.6, v.X)
+		assertFloatPrettyEqual(t, "y value", 0.8, v.Y)
+	})
+	t.Run("shouldProjectAndReflect", func(t *testing.T) {
+		v := Vec2{2, 3}
+		p := v.Project(Vec2{10, 0})
+		assertFloatPrettyEqual(t, "projected x", 2, p.X)
+		assertFloatPrettyEqual(t, "projected y", 0, p.Y)
+		r := v.Reflect(Vec2{0, -5})
+		assertFloatPrettyEqual(t, "reflected x", 2, r.X)
+		assertFloatPrettyEqual(t, "reflected y", -3, r.Y)
+	})
+	t.Run("shouldMeasureAngleBetween", func(t *testing.T) {
+		assertFloatPrettyEqual(t, "quarter turn", 90, Vec2{1, 0}.AngleBetween(Vec2{0, 2}))
+		assertFloatPrettyEqual(t, "back", -135, Vec2{1, 0}.AngleBetween(Vec2{-1, -1}))
+	})
+	t.Run("shouldLerp", func(t *testing.T) {
+		v := Vec2{0, 10}.Lerp(Vec2{10, 20}, 0.25)
+		assertFloatPrettyEqual(t, "x value", 2.5, v.X)
+		assertFloatPrettyEqual(t, "y value", 12.5, v.Y)
+	})
+	t.Run("shouldMatchSmartVector", func(t *testing.T) {
+		v := Vec2Polar(2, -135)
+		sv := v.SmartVector()
+		assertFloatPrettyEqual(t, "length", sv.length, v.Length())
+		assertFloatPrettyEqual(t, "angleDegrees", sv.angleDegrees, v.Degrees())
+		assertTrue(t, "round trip", sv.Vec2() == v)
+	})
+}
+
+func TestAngle(t *testing.T) {
+	t.Run("shouldWrapAnyMagnitude", func(t *testing.T) {
+		assertFloatPrettyEqual(t, "-90", 270, AngleDegrees(-90).Degrees())
+		assertFloatPrettyEqual(t, "360", 0, AngleDegrees(360).Degrees())
+		assertFloatPrettyEqual(t, "1000", 280, AngleDegrees(1000).Degrees())
+		assertFloatPrettyEqual(t, "-1000", 80, AngleDegrees(-1000).Degrees())
+		assertFloatPrettyEqual(t, "36000045", 45, AngleDegrees(36000045).Degrees())
+		assertFloatPrettyEqual(t, "tiny negative", 0, AngleDegrees(-1e-15).Degrees())
+		assertFloatPrettyEqual(t, "-Pi/2", 270, AngleRadians(-math.Pi/2).Degrees())
+		assertFloatPrettyEqual(t, "radians", math.Pi, AngleDegrees(-180).Radians())
+	})
+	t.Run("shouldTurnTheShortWay", func(t *testing.T) {
+		assertFloatPrettyEqual(t, "350 to 10", 20, AngleDegrees(350).Turn(AngleDegrees(10)))
+		assertFloatPrettyEqual(t, "10 to 350", -20, AngleDegrees(10).Turn(AngleDegrees(350)))
+		assertFloatPrettyEqual(t, "0 to 180", -180, AngleDegrees(0).Turn(AngleDegrees(180)))
+	})
+	t.Run("shouldClampToMaxTurn", func(t *testing.T) {
+		assertFloatPrettyEqual(t, "far", 342, AngleDegrees(0).Towards(AngleDegrees(270), 18).Degrees())
+		assertFloatPrettyEqual(t, "near", 5, AngleDegrees(0).Towards(AngleDegrees(5), 18).Degrees())
+	})
+	t.Run("shouldLerpAcrossZero", func(t *testing.T) {
+		assertFloatPrettyEqual(t, "halfway", 0, AngleDegrees(350).Lerp(AngleDegrees(10), 0.5).Degrees())
+		assertFloatPrettyEqual(t, "rounded", 0, AngleDegrees(359.6).Round().Degrees())
+	})
+}
+
+func TestCartesianToRadian(t *testing.T) {
+	negativeZero := math.Copysign(0, -1)
+	huge := math.MaxFloat64
+	cases := []struct {
+		name     string
+		x, y     float64
+		expected float64
+	}{
+		{"origin", 0, 0, 0},
+		{"negativeZeroX", negativeZero, 0, 0},
+		{"negativeZeroY", 0, negativeZero, 0},
+		{"negativeZeroBoth", negativeZero, negativeZero, 0},
+		{"east", 1, 0, 0},
+		{"southOnScreen", 0, 1, math.Pi / 2},
+		{"west", -1, 0, math.Pi},
+		{"westNegativeZeroY", -1, negativeZero, math.Pi},
+		{"northOnScreen", 0, -1, -math.Pi / 2},
+		{"northNegativeZeroX", negativeZero, -1, -math.Pi / 2},
+		{"quadrant1", 1, 1, math.Pi / 4},
+		{"quadrant2", -1, 1, math.Pi * 0.75},
+		{"quadrant3", -1, -1, math.Pi * -0.75},
+		{"quadrant4", 1, -1, -math.Pi / 4},
+		{"tinyX", 1e-300, 1, math.Pi / 2},
+		{"hugeBoth", huge, huge, math.Pi / 4},
+		{"hugeWest", -huge, 1, math.Pi},
+		{"hugeOverTiny", 1e-300, -huge, -math.Pi / 2},
+		{"infiniteX", math.Inf(-1), 5, math.Pi},
+		{"infiniteY", 5, math.Inf(1), math.Pi / 2},
+		{"infiniteBoth", math.Inf(1), math.Inf(-1), -math.Pi / 4},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			angle := cartesianToRadian(c.x, c.y)
+			assertFloatPrettyEqual(t, "angleRadians", c.expected, angle)
+			sv := NewSmartVectorCartesian(c.x, c.y)
+			assertFloatPrettyEqual(t, "angleDegrees", c.expected*180/math.Pi, sv.angleDegrees)
+		})
+	}
+}